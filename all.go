@@ -4,70 +4,143 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 )
 
+// QueryOutputAppenders implement custom logic to accumulate the results
+// of multiple DynamoDB QueryItems calls. All() calls AppendQueryOutput
+// once for each page of results.
+//
+// Implement QueryOutputAppender alongside QueryOutputUnmarshaler
+// to use All() with a custom unmarshalling QueryBuilder.
+type QueryOutputAppender interface {
+	AppendQueryOutput(out *dynamodb.QueryOutput) error
+}
+
+// MaxItems limits the number of items that All() reads.
+// If the query returns more items, All() stops and returns ErrReadLimitExceeded.
+func MaxItems(n int) func(*QueryOpts) {
+	return func(qo *QueryOpts) {
+		qo.MaxItems = n
+	}
+}
+
+// MaxPages limits the number of pages that All() reads.
+// If DynamoDB returns a page token after n pages, All() stops and returns ErrReadLimitExceeded.
+//
+// DynamoDB can return a page token on the last page of results, so the error
+// means that a page token was still present, not that results were truncated.
+func MaxPages(n int) func(*QueryOpts) {
+	return func(qo *QueryOpts) {
+		qo.MaxPages = n
+	}
+}
+
 // Query DynamoDB using a given QueryBuilder. Under the hood, this uses Query.
 //
 // The QueryBuilder 'qb' defines the query, as well as how to unmarshal the
 // result back into Go objects. The unmarshaling logic works as follows:
 //
-// 1. If qb implements UnmarshalQueryOutput, call it and return.
+// 1. If qb implements AppendQueryOutput, call it for each page.
 //
 // 2. If qb contains a field with a `ddb:"result"` struct tag,
 // unmarshal results to that field.
 //
-// 3. Unmarshal the results directly to qb.
-//
 // If the query returns a next page, then it will be loaded, until all results have been loaded from dynamodb
 // The final aggregated result will be set on the querybuilder field tagged with `ddb:"result"`
+//
+// If the Count() option is provided, the matching items are counted rather than unmarshalled.
+//
+// Use MaxItems() and MaxPages() to guard against reading an unexpectedly large number
+// of items. If a limit is exceeded, the results read so far are set and ErrReadLimitExceeded is returned.
 func (c *Client) All(ctx context.Context, qb QueryBuilder, opts ...func(*QueryOpts)) error {
-	// Get the type of the `qb` value
-	qbType := reflect.TypeOf(qb).Elem()
-
-	// Iterate over the fields in the `qb` value and find the field with the `ddb:"result"` tag
-	var resultField reflect.Value
-	for i := 0; i < qbType.NumField(); i++ {
-		field := qbType.Field(i)
-		if tag := field.Tag.Get("ddb"); tag == "result" {
-			resultField = reflect.ValueOf(qb).Elem().FieldByName(field.Name)
-			break
-		}
+	qo := QueryOpts{}
+	for _, o := range opts {
+		o(&qo)
+	}
+
+	if qo.Count != nil {
+		total := 0
+		err := c.allPages(ctx, qb, qo, func(out *dynamodb.QueryOutput) error {
+			total += int(out.Count)
+			return nil
+		})
+		*qo.Count = total
+		return err
 	}
 
-	if !resultField.IsValid() {
-		return fmt.Errorf("could not find field with `ddb:\"result\"` tag")
+	if qa, ok := qb.(QueryOutputAppender); ok {
+		return c.allPages(ctx, qb, qo, func(out *dynamodb.QueryOutput) error {
+			return qa.AppendQueryOutput(out)
+		})
 	}
 
-	// Get the type of the result field
-	resultType := resultField.Type().Elem()
+	if _, ok := qb.(QueryOutputUnmarshaler); ok {
+		return fmt.Errorf("%T implements UnmarshalQueryOutput, which only handles a single page: implement ddb.QueryOutputAppender to use it with All", qb)
+	}
+
+	// Find the field with the `ddb:"result"` tag
+	resultField, err := findResultsTag(qb)
+	if err != nil {
+		return err
+	}
+	if resultField == nil || resultField.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("could not find slice field with `ddb:\"result\"` tag")
+	}
+	field := resultField.Elem()
 
 	// Create a new slice with the same type as the result field
-	results := reflect.MakeSlice(reflect.SliceOf(resultType), 0, 0)
+	results := reflect.MakeSlice(field.Type(), 0, 0)
 
-	optsWithPage := append([]func(*QueryOpts){}, opts...)
+	err = c.allPages(ctx, qb, qo, func(out *dynamodb.QueryOutput) error {
+		// clear the previous page, as an empty page leaves the field unchanged.
+		field.Set(reflect.Zero(field.Type()))
+		err := unmarshalItems(out.Items, qb, c.schema)
+		if err != nil {
+			return err
+		}
+		results = reflect.AppendSlice(results, field)
+		return nil
+	})
+
+	field.Set(results)
+	return err
+}
 
+// allPages calls fn with the output of each page of the query.
+func (c *Client) allPages(ctx context.Context, qb QueryBuilder, qo QueryOpts, fn func(out *dynamodb.QueryOutput) error) error {
+	var items, pages int
 	for {
-		// Execute the query with the given QueryBuilder and options.
-		queryResult, err := c.Query(ctx, qb, optsWithPage...)
+		res, err := c.queryPage(ctx, qb, qo)
 		if err != nil {
 			return err
 		}
-		// Iterate over the items in the `Result` field and append them to the `items` slice
-		for i := 0; i < resultField.Len(); i++ {
-			value := resultField.Index(i)
-			convertedValue := value.Convert(resultType)
-			results = reflect.Append(results, convertedValue)
+		pages++
+		if qo.Count != nil {
+			// items aren't returned when counting, so use the count instead.
+			items += int(res.RawOutput.Count)
+		} else {
+			items += len(res.RawOutput.Items)
 		}
+
+		if qo.MaxItems > 0 && items > qo.MaxItems {
+			return ErrReadLimitExceeded
+		}
+
+		err = fn(res.RawOutput)
+		if err != nil {
+			return err
+		}
+
 		// Check if there are more pages of results to fetch.
-		if queryResult.NextPage == "" {
-			break
+		if res.NextPage == "" {
+			return nil
 		}
-		// Set the next page token in the QueryOpts slice to fetch the next page of results.
-		optsWithPage = append(optsWithPage, opts...)
-		optsWithPage = append(optsWithPage, Page(queryResult.NextPage))
+		// The next page may turn out to be empty, but it can't be read without exceeding the limit.
+		if qo.MaxPages > 0 && pages >= qo.MaxPages {
+			return ErrReadLimitExceeded
+		}
+		qo.PageToken = res.NextPage
 	}
-
-	// this is where I need to set the value of resultField to results
-	resultField.Set(results)
-	return nil
 }
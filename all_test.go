@@ -0,0 +1,152 @@
+package ddb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// appendingQuery accumulates IDs across pages with AppendQueryOutput.
+type appendingQuery struct {
+	IDs []string
+}
+
+func (q *appendingQuery) BuildQuery() (*dynamodb.QueryInput, error) {
+	return &dynamodb.QueryInput{}, nil
+}
+
+func (q *appendingQuery) UnmarshalQueryOutput(out *dynamodb.QueryOutput) error {
+	q.IDs = nil
+	return q.AppendQueryOutput(out)
+}
+
+func (q *appendingQuery) AppendQueryOutput(out *dynamodb.QueryOutput) error {
+	for _, item := range out.Items {
+		q.IDs = append(q.IDs, fakeThingID(item))
+	}
+	return nil
+}
+
+// fakeThingID returns the sort key of a fakeThing item or key.
+func fakeThingID(item map[string]types.AttributeValue) string {
+	return item["SK"].(*types.AttributeValueMemberS).Value
+}
+
+// unmarshalOnlyQuery implements UnmarshalQueryOutput but not AppendQueryOutput.
+type unmarshalOnlyQuery struct{}
+
+func (q *unmarshalOnlyQuery) BuildQuery() (*dynamodb.QueryInput, error) {
+	return &dynamodb.QueryInput{}, nil
+}
+
+func (q *unmarshalOnlyQuery) UnmarshalQueryOutput(out *dynamodb.QueryOutput) error {
+	return nil
+}
+
+func TestAllQueryOutputAppender(t *testing.T) {
+	f := &fakeDynamoDB{pages: fakeQueryPages("1", "2", "3")}
+	c := newFakeClient(t, f)
+
+	q := &appendingQuery{}
+	err := c.All(context.Background(), q, Limit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, q.IDs)
+
+	// each page is requested with the options and a single page token.
+	assert.Len(t, f.queries, 3)
+	for _, in := range f.queries {
+		assert.Equal(t, int32(1), *in.Limit)
+	}
+	assert.Nil(t, f.queries[0].ExclusiveStartKey)
+	assert.Equal(t, "2", fakeThingID(f.queries[2].ExclusiveStartKey))
+}
+
+func TestAllUnmarshalOnly(t *testing.T) {
+	c := newFakeClient(t, &fakeDynamoDB{})
+	err := c.All(context.Background(), &unmarshalOnlyQuery{})
+	assert.Error(t, err)
+}
+
+func TestAllReadLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []func(*QueryOpts)
+		want    []fakeThing
+		wantErr error
+	}{
+		{
+			name: "within limits",
+			opts: []func(*QueryOpts){MaxItems(3), MaxPages(3)},
+			want: []fakeThing{{ID: "1"}, {ID: "2"}, {ID: "3"}},
+		},
+		{
+			name:    "max items",
+			opts:    []func(*QueryOpts){MaxItems(2)},
+			want:    []fakeThing{{ID: "1"}, {ID: "2"}},
+			wantErr: ErrReadLimitExceeded,
+		},
+		{
+			name:    "max pages",
+			opts:    []func(*QueryOpts){MaxPages(1)},
+			want:    []fakeThing{{ID: "1"}},
+			wantErr: ErrReadLimitExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeClient(t, &fakeDynamoDB{pages: fakeQueryPages("1", "2", "3")})
+
+			var q listFakeThings
+			err := c.All(context.Background(), &q, tt.opts...)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, q.Result)
+		})
+	}
+}
+
+func TestAllMaxPagesTrailingToken(t *testing.T) {
+	// DynamoDB can return a LastEvaluatedKey on the last page of results,
+	// in which case the next page is empty.
+	pages := fakeQueryPages("1", "2")
+	pages[1].LastEvaluatedKey = map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "THING"}, "SK": &types.AttributeValueMemberS{Value: "2"}}
+	pages = append(pages, &dynamodb.QueryOutput{})
+
+	// the limit is exceeded because a page token is present, even though there are no more items.
+	f := &fakeDynamoDB{pages: pages}
+	c := newFakeClient(t, f)
+	var q listFakeThings
+	err := c.All(context.Background(), &q, MaxPages(2))
+	assert.Equal(t, ErrReadLimitExceeded, err)
+	assert.Equal(t, []fakeThing{{ID: "1"}, {ID: "2"}}, q.Result)
+	assert.Len(t, f.queries, 2)
+
+	// reading the empty page completes the query.
+	f = &fakeDynamoDB{pages: pages}
+	c = newFakeClient(t, f)
+	q = listFakeThings{}
+	err = c.All(context.Background(), &q, MaxPages(3))
+	assert.NoError(t, err)
+	assert.Equal(t, []fakeThing{{ID: "1"}, {ID: "2"}}, q.Result)
+	assert.Len(t, f.queries, 3)
+}
+
+func TestAllCountReadLimit(t *testing.T) {
+	key := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "THING"}, "SK": &types.AttributeValueMemberS{Value: "1"}}
+	f := &fakeDynamoDB{pages: []*dynamodb.QueryOutput{
+		{Count: 2, LastEvaluatedKey: key},
+		{Count: 2, LastEvaluatedKey: key},
+		{Count: 2},
+	}}
+	c := newFakeClient(t, f)
+
+	// items aren't returned in count mode, so the limit is checked against the count.
+	var n int
+	err := c.All(context.Background(), &countingQuery{}, Count(&n), MaxItems(3))
+	assert.Equal(t, ErrReadLimitExceeded, err)
+	assert.Len(t, f.queries, 2)
+}
@@ -0,0 +1,43 @@
+package ddb
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	// defaultMaxRetries is the default number of times that
+	// unprocessed batch items are retried.
+	defaultMaxRetries = 10
+
+	backoffBase = 50 * time.Millisecond
+	backoffMax  = 5 * time.Second
+)
+
+// backoff returns how long to wait before the given retry attempt,
+// starting from 0. It uses exponential backoff with full jitter:
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+func backoff(attempt int) time.Duration {
+	d := backoffMax
+	if attempt < 16 {
+		d = backoffBase << attempt
+		if d > backoffMax {
+			d = backoffMax
+		}
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// sleep waits for the duration d, returning early with an error
+// if the context is cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,171 @@
+package ddb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// BatchWriteError is returned by PutBatch and DeleteBatch if some of the
+// items were not written. Items are written in chunks of the client's batch
+// size, so any chunk which isn't listed in Errors was written successfully,
+// apart from the items listed in Unprocessed.
+type BatchWriteError struct {
+	// Unprocessed contains the items which were not written. This includes
+	// items which DynamoDB did not process after all retries were exhausted,
+	// as well as the items in any chunks which failed.
+	Unprocessed []Keyer
+	// Errors contains an error for each chunk which failed, ordered by chunk.
+	Errors []BatchChunkError
+}
+
+func (e *BatchWriteError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("%d items were not processed by DynamoDB after retrying", len(e.Unprocessed))
+	}
+	return fmt.Sprintf("%d items were not written: %d chunks failed, first error: %s", len(e.Unprocessed), len(e.Errors), e.Errors[0].Err)
+}
+
+// Unwrap returns the error from the first chunk which failed, if any.
+func (e *BatchWriteError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e.Errors[0].Err
+}
+
+// BatchChunkError is the error for a chunk of items in a batch write.
+type BatchChunkError struct {
+	// Chunk is the index of the chunk, starting from 0.
+	Chunk int
+	// Items are the items in the chunk.
+	Items []Keyer
+	Err   error
+}
+
+func (e BatchChunkError) Error() string {
+	return fmt.Sprintf("chunk %d: %s", e.Chunk, e.Err)
+}
+
+func (e BatchChunkError) Unwrap() error {
+	return e.Err
+}
+
+// batchWrite calls BatchWriteItem with the write requests, split into chunks
+// of the client's batch size. items[i] must be the item written by requests[i].
+//
+// Chunks are written by a pool of workers, sized by the client's batch concurrency.
+// Unprocessed items are retried with exponential backoff. If a chunk fails, the
+// remaining chunks are still written unless the context is cancelled, and then
+// a *BatchWriteError is returned.
+func (c *Client) batchWrite(ctx context.Context, requests []types.WriteRequest, items []Keyer) error {
+	type chunkResult struct {
+		start, end  int
+		unprocessed []Keyer
+		err         error
+	}
+	var chunks []chunkResult
+	for i := 0; i < len(requests); i += c.batchSize {
+		end := len(requests)
+		if i+c.batchSize < end {
+			end = i + c.batchSize
+		}
+		chunks = append(chunks, chunkResult{start: i, end: end})
+	}
+
+	workers := c.batchConcurrency
+	if workers > len(chunks) {
+		workers = len(chunks)
+	}
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				ch := &chunks[i]
+				// don't start any new chunks if the context has been cancelled.
+				if err := ctx.Err(); err != nil {
+					ch.err = err
+					continue
+				}
+				ch.unprocessed, ch.err = c.writeChunk(ctx, requests[ch.start:ch.end], items[ch.start:ch.end])
+			}
+		}()
+	}
+	for i := range chunks {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	var bwe BatchWriteError
+	for i, ch := range chunks {
+		if ch.err != nil {
+			bwe.Errors = append(bwe.Errors, BatchChunkError{Chunk: i, Items: items[ch.start:ch.end], Err: ch.err})
+			bwe.Unprocessed = append(bwe.Unprocessed, items[ch.start:ch.end]...)
+			continue
+		}
+		bwe.Unprocessed = append(bwe.Unprocessed, ch.unprocessed...)
+	}
+	if len(bwe.Unprocessed) > 0 {
+		return &bwe
+	}
+	return nil
+}
+
+// writeChunk calls BatchWriteItem for a single chunk of write requests,
+// retrying any unprocessed items. It returns the items which were
+// still unprocessed after the retries were exhausted.
+func (c *Client) writeChunk(ctx context.Context, requests []types.WriteRequest, items []Keyer) ([]Keyer, error) {
+	for attempt := 0; ; attempt++ {
+		out, err := c.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				c.table: requests,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		retry := out.UnprocessedItems[c.table]
+		if len(retry) == 0 {
+			return nil, nil
+		}
+		if attempt >= c.maxBatchRetries {
+			return c.schema.unprocessedItems(requests, items, retry), nil
+		}
+		if err := sleep(ctx, backoff(attempt)); err != nil {
+			return nil, err
+		}
+		items = c.schema.unprocessedItems(requests, items, retry)
+		requests = retry
+	}
+}
+
+// unprocessedItems returns the items corresponding to the unprocessed write requests.
+func (s TableSchema) unprocessedItems(requests []types.WriteRequest, items []Keyer, unprocessed []types.WriteRequest) []Keyer {
+	byKey := make(map[GetKey]Keyer, len(requests))
+	for i, r := range requests {
+		byKey[s.writeRequestKey(r)] = items[i]
+	}
+	result := make([]Keyer, len(unprocessed))
+	for i, r := range unprocessed {
+		result[i] = byKey[s.writeRequestKey(r)]
+	}
+	return result
+}
+
+// writeRequestKey returns the primary key of the item written by a write request.
+func (s TableSchema) writeRequestKey(r types.WriteRequest) GetKey {
+	if r.PutRequest != nil {
+		return s.getKey(r.PutRequest.Item)
+	}
+	if r.DeleteRequest != nil {
+		return s.getKey(r.DeleteRequest.Key)
+	}
+	return GetKey{}
+}
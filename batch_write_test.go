@@ -0,0 +1,235 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// throttledDynamoDB returns the items with the given IDs as unprocessed
+// until they have been retried the given number of times.
+type throttledDynamoDB struct {
+	DynamoDBAPI
+
+	throttle map[string]int
+	requests [][]types.WriteRequest
+}
+
+func (f *throttledDynamoDB) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	wr := params.RequestItems["test-table"]
+	f.requests = append(f.requests, wr)
+
+	out := &dynamodb.BatchWriteItemOutput{}
+	for _, r := range wr {
+		id := DefaultTableSchema().writeRequestKey(r).SK
+		if f.throttle[id] > 0 {
+			f.throttle[id]--
+			if out.UnprocessedItems == nil {
+				out.UnprocessedItems = map[string][]types.WriteRequest{}
+			}
+			out.UnprocessedItems["test-table"] = append(out.UnprocessedItems["test-table"], r)
+		}
+	}
+	return out, nil
+}
+
+func TestBatchWriteRetries(t *testing.T) {
+	tests := []struct {
+		name            string
+		throttle        map[string]int
+		opts            []func(*Client)
+		wantRequests    int
+		wantUnprocessed []Keyer
+	}{
+		{
+			name:         "no unprocessed items",
+			throttle:     map[string]int{},
+			wantRequests: 2,
+		},
+		{
+			name:         "unprocessed items are retried",
+			throttle:     map[string]int{"1": 2, "3": 1},
+			wantRequests: 5,
+		},
+		{
+			name:            "retries exhausted",
+			throttle:        map[string]int{"1": 2, "3": 1},
+			opts:            []func(*Client){WithMaxBatchRetries(1)},
+			wantRequests:    4,
+			wantUnprocessed: []Keyer{fakeThing{ID: "1"}},
+		},
+		{
+			name:            "retries disabled",
+			throttle:        map[string]int{"1": 1, "3": 1},
+			opts:            []func(*Client){WithMaxBatchRetries(0)},
+			wantRequests:    2,
+			wantUnprocessed: []Keyer{fakeThing{ID: "1"}, fakeThing{ID: "3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, op := range []string{"put", "delete"} {
+				throttle := map[string]int{}
+				for k, v := range tt.throttle {
+					throttle[k] = v
+				}
+				f := &throttledDynamoDB{throttle: throttle}
+				opts := append([]func(*Client){WithDynamoDBClient(f), WithBatchSize(2)}, tt.opts...)
+				c, err := New(context.Background(), "test-table", opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				items := []Keyer{fakeThing{ID: "1"}, fakeThing{ID: "2"}, fakeThing{ID: "3"}}
+				if op == "put" {
+					err = c.PutBatch(context.Background(), items...)
+				} else {
+					err = c.DeleteBatch(context.Background(), items...)
+				}
+				assert.Len(t, f.requests, tt.wantRequests, op)
+
+				if tt.wantUnprocessed == nil {
+					assert.NoError(t, err, op)
+					continue
+				}
+				var bwe *BatchWriteError
+				assert.True(t, errors.As(err, &bwe), op)
+				assert.Equal(t, tt.wantUnprocessed, bwe.Unprocessed, op)
+			}
+		})
+	}
+}
+
+func TestInvalidBatchRetries(t *testing.T) {
+	_, err := New(context.Background(), "test-table", WithDynamoDBClient(&fakeDynamoDB{}), WithMaxBatchRetries(-1))
+	assert.Equal(t, ErrInvalidBatchRetries, err)
+}
+
+// concurrentDynamoDB records the maximum number of concurrent BatchWriteItem
+// calls, and fails any chunk containing an item with an ID in 'fail'.
+type concurrentDynamoDB struct {
+	DynamoDBAPI
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	calls       int
+	fail        map[string]bool
+	// cancel is called on the first BatchWriteItem call if set.
+	cancel context.CancelFunc
+}
+
+func (f *concurrentDynamoDB) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	f.mu.Lock()
+	f.calls++
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	if f.cancel != nil {
+		f.cancel()
+	}
+	f.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inFlight--
+
+	for _, r := range params.RequestItems["test-table"] {
+		if f.fail[DefaultTableSchema().writeRequestKey(r).SK] {
+			return nil, errors.New("write failed")
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func fakeThings(n int) []Keyer {
+	items := make([]Keyer, n)
+	for i := range items {
+		items[i] = fakeThing{ID: fmt.Sprint(i)}
+	}
+	return items
+}
+
+func TestBatchConcurrency(t *testing.T) {
+	f := &concurrentDynamoDB{}
+	c, err := New(context.Background(), "test-table", WithDynamoDBClient(f), WithBatchSize(2), WithBatchConcurrency(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.PutBatch(context.Background(), fakeThings(20)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 10, f.calls)
+	assert.Equal(t, 3, f.maxInFlight)
+}
+
+func TestBatchConcurrencyErrors(t *testing.T) {
+	f := &concurrentDynamoDB{fail: map[string]bool{"2": true, "7": true}}
+	c, err := New(context.Background(), "test-table", WithDynamoDBClient(f), WithBatchSize(2), WithBatchConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := fakeThings(10)
+	err = c.DeleteBatch(context.Background(), items...)
+
+	// the other chunks are still written.
+	assert.Equal(t, 5, f.calls)
+
+	var bwe *BatchWriteError
+	assert.True(t, errors.As(err, &bwe))
+	assert.Len(t, bwe.Errors, 2)
+	assert.Equal(t, 1, bwe.Errors[0].Chunk)
+	assert.Equal(t, items[2:4], bwe.Errors[0].Items)
+	assert.Equal(t, 3, bwe.Errors[1].Chunk)
+	assert.Equal(t, items[6:8], bwe.Errors[1].Items)
+	assert.Equal(t, append(items[2:4:4], items[6:8]...), bwe.Unprocessed)
+}
+
+func TestBatchConcurrencyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &concurrentDynamoDB{cancel: cancel}
+	c, err := New(ctx, "test-table", WithDynamoDBClient(f), WithBatchSize(2), WithBatchConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.PutBatch(ctx, fakeThings(20)...)
+	assert.True(t, errors.Is(err, context.Canceled))
+	// chunks aren't started after the context is cancelled.
+	assert.LessOrEqual(t, f.calls, 2)
+}
+
+func TestInvalidBatchConcurrency(t *testing.T) {
+	_, err := New(context.Background(), "test-table", WithDynamoDBClient(&fakeDynamoDB{}), WithBatchConcurrency(0))
+	assert.Equal(t, ErrInvalidBatchConcurrency, err)
+}
+
+func TestBatchWriteRejectsVersionedItems(t *testing.T) {
+	ctx := context.Background()
+	db := &throttledDynamoDB{}
+	c, err := New(ctx, "test-table", WithDynamoDBClient(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.PutBatch(ctx, testitem{}, &versionedItem{Version: 1})
+	assert.EqualError(t, err, "PutBatch doesn't support optimistic locking, but *ddb.versionedItem is versioned: use Put, Delete or a transaction to write versioned items")
+
+	err = c.DeleteBatch(ctx, taggedVersionItem{Rev: 1})
+	assert.EqualError(t, err, "DeleteBatch doesn't support optimistic locking, but ddb.taggedVersionItem is versioned: use Put, Delete or a transaction to write versioned items")
+
+	assert.Empty(t, db.requests)
+}
@@ -15,6 +15,23 @@ type Tokenizer interface {
 	UnmarshalToken(ctx context.Context, s string) (map[string]types.AttributeValue, error)
 }
 
+// DynamoDBAPI is the subset of the DynamoDB API used by the ddb package.
+// It is satisfied by *dynamodb.Client, and allows a fake, instrumented or
+// recording client to be provided with WithDynamoDBClient().
+type DynamoDBAPI interface {
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+var _ DynamoDBAPI = &dynamodb.Client{}
+
 var _ Storage = &Client{}
 
 // Client is a thin wrapper over the native DynamoDB client.
@@ -22,19 +39,29 @@ var _ Storage = &Client{}
 // in a more ergonomic fashion than the native client.
 type Client struct {
 	batchSize int
-	table     string
-	client    *dynamodb.Client
-	tokenizer Tokenizer
+	// maxBatchRetries is the number of times unprocessed
+	// items in batch operations are retried.
+	maxBatchRetries int
+	// batchConcurrency is the number of chunks
+	// written in parallel by batch operations.
+	batchConcurrency int
+	table            string
+	client           DynamoDBAPI
+	tokenizer        Tokenizer
+	schema           TableSchema
 }
 
 // New creates a new DynamoDB Client.
 func New(ctx context.Context, table string, opts ...func(*Client)) (*Client, error) {
 	c := &Client{
-		table:     table,
-		batchSize: 25,
+		table:            table,
+		batchSize:        25,
+		maxBatchRetries:  defaultMaxRetries,
+		batchConcurrency: 1,
 		// default to the JSONTokenizer.
 		// this can be overridden by providing ddb.WithPageTokenizer().
 		tokenizer: &JSONTokenizer{},
+		schema:    DefaultTableSchema(),
 	}
 
 	for _, o := range opts {
@@ -44,6 +71,15 @@ func New(ctx context.Context, table string, opts ...func(*Client)) (*Client, err
 	if c.batchSize > 25 || c.batchSize < 1 {
 		return nil, ErrInvalidBatchSize
 	}
+	if c.maxBatchRetries < 0 {
+		return nil, ErrInvalidBatchRetries
+	}
+	if c.batchConcurrency < 1 {
+		return nil, ErrInvalidBatchConcurrency
+	}
+	if err := c.schema.Validate(); err != nil {
+		return nil, err
+	}
 
 	if c.client == nil {
 		cfg, err := config.LoadDefaultConfig(ctx)
@@ -56,8 +92,10 @@ func New(ctx context.Context, table string, opts ...func(*Client)) (*Client, err
 	return c, nil
 }
 
-// WithDynamoDBClient allows a custom dynamodb.Client to be provided.
-func WithDynamoDBClient(d *dynamodb.Client) func(*Client) {
+// WithDynamoDBClient allows a custom DynamoDB client to be provided.
+// This can be a *dynamodb.Client, or any other implementation of DynamoDBAPI
+// such as a fake for unit testing.
+func WithDynamoDBClient(d DynamoDBAPI) func(*Client) {
 	return func(c *Client) {
 		c.client = d
 	}
@@ -70,6 +108,23 @@ func WithBatchSize(batchSize int) func(*Client) {
 	}
 }
 
+// WithMaxBatchRetries sets how many times unprocessed items are retried by
+// PutBatch, DeleteBatch and GetBatch. Retries use exponential backoff with jitter.
+// Setting maxRetries to 0 disables retries. The default is 10.
+func WithMaxBatchRetries(maxRetries int) func(*Client) {
+	return func(c *Client) {
+		c.maxBatchRetries = maxRetries
+	}
+}
+
+// WithBatchConcurrency sets how many chunks PutBatch and DeleteBatch write in parallel.
+// By default, chunks are written one after another.
+func WithBatchConcurrency(n int) func(*Client) {
+	return func(c *Client) {
+		c.batchConcurrency = n
+	}
+}
+
 // WithPageTokenizer allows a tokenizer to be provided for turning
 // LastEvaluatedKey items into strings.
 func WithPageTokenizer(e Tokenizer) func(*Client) {
@@ -78,7 +133,16 @@ func WithPageTokenizer(e Tokenizer) func(*Client) {
 	}
 }
 
+// Client returns the underlying *dynamodb.Client.
+// If a custom DynamoDBAPI implementation was provided with WithDynamoDBClient()
+// which isn't a *dynamodb.Client, Client returns nil. Use API() to access it instead.
 func (c *Client) Client() *dynamodb.Client {
+	dc, _ := c.client.(*dynamodb.Client)
+	return dc
+}
+
+// API returns the underlying DynamoDB API client.
+func (c *Client) API() DynamoDBAPI {
 	return c.client
 }
 
@@ -0,0 +1,305 @@
+package ddb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDynamoDB is a DynamoDBAPI implementation used in unit tests.
+// Query returns each of the pages in order, and write calls are recorded.
+// Calling a method which isn't implemented panics.
+type fakeDynamoDB struct {
+	DynamoDBAPI
+
+	pages              []*dynamodb.QueryOutput
+	queries            []*dynamodb.QueryInput
+	transactWriteItems []*dynamodb.TransactWriteItemsInput
+	// transactErrs are returned by successive calls to TransactWriteItems.
+	transactErrs []error
+}
+
+func (f *fakeDynamoDB) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	f.queries = append(f.queries, params)
+	out := f.pages[0]
+	f.pages = f.pages[1:]
+	return out, nil
+}
+
+func (f *fakeDynamoDB) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	f.transactWriteItems = append(f.transactWriteItems, params)
+	if len(f.transactErrs) > 0 {
+		err := f.transactErrs[0]
+		f.transactErrs = f.transactErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+type fakeThing struct {
+	ID string
+}
+
+func (f fakeThing) DDBKeys() (Keys, error) {
+	return Keys{PK: "THING", SK: f.ID}, nil
+}
+
+type listFakeThings struct {
+	Result []fakeThing `ddb:"result"`
+}
+
+func (l *listFakeThings) BuildQuery() (*dynamodb.QueryInput, error) {
+	return &dynamodb.QueryInput{}, nil
+}
+
+func fakeThingItem(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "THING"},
+		"SK": &types.AttributeValueMemberS{Value: id},
+		"ID": &types.AttributeValueMemberS{Value: id},
+	}
+}
+
+func newFakeClient(t *testing.T, f *fakeDynamoDB) *Client {
+	c, err := New(context.Background(), "test-table", WithDynamoDBClient(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClientWithFakeAPI(t *testing.T) {
+	f := &fakeDynamoDB{}
+	c := newFakeClient(t, f)
+
+	assert.Equal(t, f, c.API())
+	// Client() only returns a *dynamodb.Client.
+	assert.Nil(t, c.Client())
+}
+
+func TestQueryWithFakeAPI(t *testing.T) {
+	f := &fakeDynamoDB{
+		pages: []*dynamodb.QueryOutput{
+			{Items: []map[string]types.AttributeValue{fakeThingItem("1")}},
+		},
+	}
+	c := newFakeClient(t, f)
+
+	var q listFakeThings
+	_, err := c.Query(context.Background(), &q, Limit(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []fakeThing{{ID: "1"}}, q.Result)
+	assert.Equal(t, "test-table", *f.queries[0].TableName)
+	assert.Equal(t, int32(10), *f.queries[0].Limit)
+}
+
+func TestAllWithFakeAPI(t *testing.T) {
+	f := &fakeDynamoDB{
+		pages: []*dynamodb.QueryOutput{
+			{
+				Items:            []map[string]types.AttributeValue{fakeThingItem("1")},
+				LastEvaluatedKey: map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "THING"}, "SK": &types.AttributeValueMemberS{Value: "1"}},
+			},
+			{Items: []map[string]types.AttributeValue{fakeThingItem("2")}},
+		},
+	}
+	c := newFakeClient(t, f)
+
+	var q listFakeThings
+	err := c.All(context.Background(), &q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []fakeThing{{ID: "1"}, {ID: "2"}}, q.Result)
+	assert.Len(t, f.queries, 2)
+	assert.Equal(t, "1", f.queries[1].ExclusiveStartKey["SK"].(*types.AttributeValueMemberS).Value)
+}
+
+func TestTransactWriteItemsWithFakeAPI(t *testing.T) {
+	f := &fakeDynamoDB{}
+	c := newFakeClient(t, f)
+
+	tx := c.NewTransaction()
+	tx.Put(fakeThing{ID: "1"})
+	tx.Delete(fakeThing{ID: "2"})
+	err := tx.Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := f.transactWriteItems[0].TransactItems
+	assert.Len(t, got, 2)
+	assert.Equal(t, "test-table", *got[0].Put.TableName)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "1"}, got[0].Put.Item["SK"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2"}, got[1].Delete.Key["SK"])
+}
+
+func TestTransactionError(t *testing.T) {
+	f := &fakeDynamoDB{
+		transactErrs: []error{&types.TransactionCanceledException{
+			CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("None")},
+				{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("The conditional request failed")},
+				{Code: aws.String("TransactionConflict")},
+			},
+		}},
+	}
+	c := newFakeClient(t, f)
+
+	tx := c.NewTransaction()
+	tx.Put(fakeThing{ID: "1"})
+	tx.Delete(fakeThing{ID: "2"}, IfExists())
+	tx.ConditionCheck(GetKey{PK: "THING", SK: "3"}, IfExists())
+	err := tx.Execute(context.Background())
+
+	assert.ErrorIs(t, err, ErrConditionFailed)
+	var te *TransactionError
+	if !assert.ErrorAs(t, err, &te) {
+		return
+	}
+	assert.Len(t, te.Cancellations, 2)
+	assert.Equal(t, 1, te.Cancellations[0].Index)
+	assert.Equal(t, CancelConditionalCheckFailed, te.Cancellations[0].Code)
+	assert.Equal(t, fakeThing{ID: "2"}, te.Cancellations[0].Keyer)
+	assert.Equal(t, CancelTransactionConflict, te.Cancellations[1].Code)
+	assert.Nil(t, te.Cancellations[1].Keyer)
+	assert.Equal(t, &GetKey{PK: "THING", SK: "3"}, te.Cancellations[1].Item.ConditionCheck)
+	assert.Equal(t, "transaction cancelled: operation 1: ConditionalCheckFailed: The conditional request failed; operation 2: TransactionConflict", err.Error())
+
+	// the underlying exception can still be read.
+	var tce *types.TransactionCanceledException
+	assert.ErrorAs(t, err, &tce)
+}
+
+func TestTransactionErrorIndexIsPayloadOrder(t *testing.T) {
+	f := &fakeDynamoDB{
+		transactErrs: []error{&types.TransactionCanceledException{
+			CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("None")},
+				{Code: aws.String("ConditionalCheckFailed")},
+			},
+		}},
+	}
+	c := newFakeClient(t, f)
+
+	// the delete is added first, but puts are sent first.
+	tx := c.NewTransaction()
+	tx.Delete(fakeThing{ID: "1"}, IfExists())
+	tx.Put(fakeThing{ID: "2"})
+	err := tx.Execute(context.Background())
+
+	var te *TransactionError
+	if !assert.ErrorAs(t, err, &te) {
+		return
+	}
+	assert.Equal(t, 1, te.Cancellations[0].Index)
+	assert.Equal(t, fakeThing{ID: "1"}, te.Cancellations[0].Keyer)
+	assert.Equal(t, fakeThing{ID: "1"}, te.Cancellations[0].Item.Delete)
+}
+
+func TestTransactionRetryOnConflict(t *testing.T) {
+	conflict := func() error {
+		return &types.TransactionCanceledException{
+			CancellationReasons: []types.CancellationReason{{Code: aws.String("TransactionConflict")}},
+		}
+	}
+	ctx := context.Background()
+
+	// conflicts are retried with the same token.
+	f := &fakeDynamoDB{transactErrs: []error{conflict(), conflict(), nil}}
+	c := newFakeClient(t, f)
+	tx := c.NewTransaction(ClientRequestToken("token-1"), RetryOnConflict(2))
+	tx.Put(fakeThing{ID: "1"})
+	err := tx.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, f.transactWriteItems, 3)
+	for _, in := range f.transactWriteItems {
+		assert.Equal(t, "token-1", *in.ClientRequestToken)
+	}
+
+	// a token is generated if one isn't provided, and the error is returned once retries are exhausted.
+	f = &fakeDynamoDB{transactErrs: []error{conflict(), conflict()}}
+	c = newFakeClient(t, f)
+	err = c.TransactWriteItems(ctx, []TransactWriteItem{{Put: fakeThing{ID: "1"}}}, RetryOnConflict(1))
+	var te *TransactionError
+	if assert.ErrorAs(t, err, &te) {
+		assert.Equal(t, CancelTransactionConflict, te.Cancellations[0].Code)
+	}
+	assert.Len(t, f.transactWriteItems, 2)
+	assert.NotEmpty(t, *f.transactWriteItems[0].ClientRequestToken)
+	assert.Equal(t, *f.transactWriteItems[0].ClientRequestToken, *f.transactWriteItems[1].ClientRequestToken)
+
+	// other cancellations aren't retried.
+	f = &fakeDynamoDB{transactErrs: []error{&types.TransactionCanceledException{
+		CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("TransactionConflict")},
+			{Code: aws.String("ConditionalCheckFailed")},
+		},
+	}}}
+	c = newFakeClient(t, f)
+	tx = c.NewTransaction(RetryOnConflict(3))
+	tx.Put(fakeThing{ID: "1"})
+	tx.Put(fakeThing{ID: "2"}, IfNotExists())
+	err = tx.Execute(ctx)
+	assert.ErrorIs(t, err, ErrConditionFailed)
+	assert.Len(t, f.transactWriteItems, 1)
+}
+
+type fakeVersionedThing struct {
+	ID      string
+	Version int `ddb:"version"`
+}
+
+func (f *fakeVersionedThing) DDBKeys() (Keys, error) {
+	return Keys{PK: "THING", SK: f.ID}, nil
+}
+
+func TestTransactionErrorVersionConflict(t *testing.T) {
+	conditionFailed := func() error {
+		return &types.TransactionCanceledException{
+			CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("None")},
+				{Code: aws.String("ConditionalCheckFailed")},
+			},
+		}
+	}
+	ctx := context.Background()
+
+	// the version check is the only condition, so the item has been modified concurrently.
+	f := &fakeDynamoDB{transactErrs: []error{conditionFailed()}}
+	c := newFakeClient(t, f)
+	tx := c.NewTransaction()
+	tx.Put(fakeThing{ID: "1"})
+	tx.Put(&fakeVersionedThing{ID: "2", Version: 1})
+	err := tx.Execute(ctx)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.NotErrorIs(t, err, ErrConditionFailed)
+	var te *TransactionError
+	if assert.ErrorAs(t, err, &te) {
+		assert.Equal(t, 1, te.Cancellations[0].Index)
+		assert.True(t, te.Cancellations[0].VersionConflict)
+	}
+
+	// the item has another condition, so it isn't known which condition failed.
+	f = &fakeDynamoDB{transactErrs: []error{conditionFailed()}}
+	c = newFakeClient(t, f)
+	tx = c.NewTransaction()
+	tx.Put(fakeThing{ID: "1"})
+	tx.Put(&fakeVersionedThing{ID: "2", Version: 1}, IfExists())
+	err = tx.Execute(ctx)
+	assert.ErrorIs(t, err, ErrConditionFailed)
+	assert.NotErrorIs(t, err, ErrVersionConflict)
+	if assert.ErrorAs(t, err, &te) {
+		assert.False(t, te.Cancellations[0].VersionConflict)
+	}
+}
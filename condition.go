@@ -0,0 +1,165 @@
+package ddb
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ConditionExpression is a DynamoDB condition expression along with
+// the placeholder names and values it refers to.
+//
+// see: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Expressions.ConditionExpressions.html
+type ConditionExpression struct {
+	Expression string
+	Names      map[string]string
+	Values     map[string]types.AttributeValue
+}
+
+// WriteOpts customise write operations such as Put and Delete.
+type WriteOpts struct {
+	// Condition must be met for the write to succeed.
+	Condition *ConditionExpression
+	// IfNotExists only allows the write if an item with the same
+	// primary key doesn't exist in the table.
+	IfNotExists bool
+	// IfExists only allows the write if an item with the same
+	// primary key already exists in the table.
+	IfExists bool
+	// ReturnValues causes the write to return the item's attributes
+	// as they appeared before or after the write.
+	ReturnValues types.ReturnValue
+	// ReturnItem is the item the returned attributes are unmarshalled into.
+	ReturnItem Keyer
+}
+
+// IfNotExists only allows the write to succeed if an item with
+// the same primary key does not already exist.
+// If the item exists, ErrConditionFailed is returned.
+func IfNotExists() func(*WriteOpts) {
+	return func(wo *WriteOpts) {
+		wo.IfNotExists = true
+	}
+}
+
+// IfExists only allows the write to succeed if an item with
+// the same primary key already exists.
+// If the item doesn't exist, ErrConditionFailed is returned.
+func IfExists() func(*WriteOpts) {
+	return func(wo *WriteOpts) {
+		wo.IfExists = true
+	}
+}
+
+// Condition only allows the write to succeed if the condition expression is met.
+// If the condition isn't met, ErrConditionFailed is returned.
+// The names and values arguments may be nil if the expression doesn't use placeholders.
+//
+// For example:
+//
+//	db.Delete(ctx, item, ddb.Condition("#status = :status",
+//		map[string]string{"#status": "Status"},
+//		map[string]types.AttributeValue{":status": &types.AttributeValueMemberS{Value: "CLOSED"}},
+//	))
+//
+// If Condition is provided multiple times, all of the conditions must be met.
+func Condition(expr string, names map[string]string, values map[string]types.AttributeValue) func(*WriteOpts) {
+	return func(wo *WriteOpts) {
+		wo.Condition = wo.Condition.and(ConditionExpression{Expression: expr, Names: names, Values: values})
+	}
+}
+
+// and combines two condition expressions, returning a new expression
+// which is met only if both are met. c may be nil.
+func (c *ConditionExpression) and(other ConditionExpression) *ConditionExpression {
+	if c == nil {
+		return &other
+	}
+	out := &ConditionExpression{
+		Expression: "(" + c.Expression + ") AND (" + other.Expression + ")",
+	}
+	for _, src := range []map[string]string{c.Names, other.Names} {
+		for k, v := range src {
+			if out.Names == nil {
+				out.Names = map[string]string{}
+			}
+			out.Names[k] = v
+		}
+	}
+	for _, src := range []map[string]types.AttributeValue{c.Values, other.Values} {
+		for k, v := range src {
+			if out.Values == nil {
+				out.Values = map[string]types.AttributeValue{}
+			}
+			out.Values[k] = v
+		}
+	}
+	return out
+}
+
+// HasCondition returns true if the write is conditional.
+func (wo WriteOpts) HasCondition() bool {
+	return wo.Condition != nil || wo.IfNotExists || wo.IfExists
+}
+
+// buildCondition returns the combined condition expression for the write,
+// or nil if the write is unconditional. The table schema is used to
+// check whether the item exists using its partition key.
+func (wo WriteOpts) buildCondition(s TableSchema) *ConditionExpression {
+	var parts []string
+	if wo.IfNotExists {
+		parts = append(parts, "attribute_not_exists(#ddbpk)")
+	}
+	if wo.IfExists {
+		parts = append(parts, "attribute_exists(#ddbpk)")
+	}
+	var keyCondition *ConditionExpression
+	if len(parts) > 0 {
+		keyCondition = &ConditionExpression{
+			Expression: strings.Join(parts, " AND "),
+			Names:      map[string]string{"#ddbpk": s.PartitionKey},
+		}
+	}
+
+	if wo.Condition == nil {
+		return keyCondition
+	}
+	if keyCondition == nil {
+		return wo.Condition
+	}
+	return keyCondition.and(*wo.Condition)
+}
+
+// conditionArgs returns the condition expression arguments for a DynamoDB API call.
+// DynamoDB rejects empty name and value maps, so they are returned as nil if unused.
+func conditionArgs(c *ConditionExpression) (*string, map[string]string, map[string]types.AttributeValue) {
+	if c == nil {
+		return nil, nil, nil
+	}
+	expr := c.Expression
+	var names map[string]string
+	if len(c.Names) > 0 {
+		names = c.Names
+	}
+	var values map[string]types.AttributeValue
+	if len(c.Values) > 0 {
+		values = c.Values
+	}
+	return &expr, names, values
+}
+
+// conditionError converts a conditional check failure from DynamoDB into ErrConditionFailed,
+// or ErrVersionConflict if the item being written is versioned and the version check
+// is the write's only condition. DynamoDB doesn't report which part of a condition failed,
+// so if the write has other conditions ErrConditionFailed is returned.
+func conditionError(err error, version *itemVersion, wo WriteOpts) error {
+	var ccf *types.ConditionalCheckFailedException
+	if !errors.As(err, &ccf) {
+		return err
+	}
+	if version != nil && !wo.HasCondition() {
+		return ErrVersionConflict
+	}
+	return ErrConditionFailed
+}
@@ -0,0 +1,72 @@
+package ddb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteOpts_buildCondition(t *testing.T) {
+	status := map[string]string{"#status": "Status"}
+	closed := map[string]types.AttributeValue{":closed": &types.AttributeValueMemberS{Value: "CLOSED"}}
+	pk := map[string]string{"#ddbpk": "PK"}
+	open := map[string]types.AttributeValue{":open": &types.AttributeValueMemberS{Value: "OPEN"}}
+
+	tests := []struct {
+		name string
+		give []func(*WriteOpts)
+		want *ConditionExpression
+	}{
+		{
+			name: "unconditional",
+			want: nil,
+		},
+		{
+			name: "if not exists",
+			give: []func(*WriteOpts){IfNotExists()},
+			want: &ConditionExpression{Expression: "attribute_not_exists(#ddbpk)", Names: pk},
+		},
+		{
+			name: "if exists",
+			give: []func(*WriteOpts){IfExists()},
+			want: &ConditionExpression{Expression: "attribute_exists(#ddbpk)", Names: pk},
+		},
+		{
+			name: "condition",
+			give: []func(*WriteOpts){Condition("#status = :closed", status, closed)},
+			want: &ConditionExpression{Expression: "#status = :closed", Names: status, Values: closed},
+		},
+		{
+			name: "multiple conditions",
+			give: []func(*WriteOpts){Condition("#status = :closed", status, closed), Condition("#status <> :open", status, open)},
+			want: &ConditionExpression{
+				Expression: "(#status = :closed) AND (#status <> :open)",
+				Names:      status,
+				Values: map[string]types.AttributeValue{
+					":closed": closed[":closed"],
+					":open":   open[":open"],
+				},
+			},
+		},
+		{
+			name: "if exists and condition",
+			give: []func(*WriteOpts){IfExists(), Condition("#status = :closed", status, closed)},
+			want: &ConditionExpression{
+				Expression: "(attribute_exists(#ddbpk)) AND (#status = :closed)",
+				Names:      map[string]string{"#ddbpk": "PK", "#status": "Status"},
+				Values:     closed,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wo := WriteOpts{}
+			for _, o := range tt.give {
+				o(&wo)
+			}
+			assert.Equal(t, tt.want, wo.buildCondition(DefaultTableSchema()))
+			assert.Equal(t, tt.want != nil, wo.HasCondition())
+		})
+	}
+}
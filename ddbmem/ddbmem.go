@@ -0,0 +1,39 @@
+// Package ddbmem provides an in-memory implementation of ddb.Storage.
+//
+// Unlike ddbmock, which returns canned results, ddbmem stores items
+// in memory and evaluates queries against them. Items written with Put
+// are returned by subsequent calls to Get and Query, which makes it
+// useful for unit testing access patterns without a live DynamoDB table.
+//
+// ddbmem supports the subset of the DynamoDB API used by the ddb package.
+// You should still write integration tests against a live DynamoDB table
+// for any access patterns which rely on DynamoDB-specific behaviour.
+package ddbmem
+
+import (
+	"context"
+
+	"github.com/common-fate/ddb"
+)
+
+// New creates a ddb.Client which is backed by a new in-memory Table.
+// The table uses the client's schema, which can be set with ddb.WithTableSchema().
+//
+// For example:
+//
+//	db, _ := ddbmem.New(ctx)
+//	db.Put(ctx, Apple{ID: "1", Color: "red"})
+//
+//	var q ListApplesByColor{Color: "red"}
+//	db.Query(ctx, &q)
+//	// q now contains the apple written above.
+func New(ctx context.Context, opts ...func(*ddb.Client)) (*ddb.Client, error) {
+	t := NewTable()
+	opts = append(opts, ddb.WithDynamoDBClient(t))
+	c, err := ddb.New(ctx, "ddbmem", opts...)
+	if err != nil {
+		return nil, err
+	}
+	t.setSchema(c.Schema())
+	return c, nil
+}
@@ -0,0 +1,865 @@
+package ddbmem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// This file contains a parser and evaluator for DynamoDB condition expressions.
+// It is used to evaluate KeyConditionExpression, FilterExpression and
+// ConditionExpression arguments against items held in memory.
+//
+// see: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Expressions.OperatorsAndFunctions.html
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenName  // #name
+	tokenValue // :value
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+	tokenDot
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lex splits an expression into tokens.
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	r := []rune(expr)
+	for i := 0; i < len(r); {
+		ch := r[i]
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch == '(':
+			tokens = append(tokens, token{tokenLParen, "("})
+			i++
+		case ch == ')':
+			tokens = append(tokens, token{tokenRParen, ")"})
+			i++
+		case ch == '[':
+			tokens = append(tokens, token{tokenLBracket, "["})
+			i++
+		case ch == ']':
+			tokens = append(tokens, token{tokenRBracket, "]"})
+			i++
+		case ch == ',':
+			tokens = append(tokens, token{tokenComma, ","})
+			i++
+		case ch == '.':
+			tokens = append(tokens, token{tokenDot, "."})
+			i++
+		case ch == '=' || ch == '+' || ch == '-':
+			tokens = append(tokens, token{tokenOperator, string(ch)})
+			i++
+		case ch == '<' || ch == '>':
+			op := string(ch)
+			if i+1 < len(r) && (r[i+1] == '=' || (ch == '<' && r[i+1] == '>')) {
+				op += string(r[i+1])
+			}
+			tokens = append(tokens, token{tokenOperator, op})
+			i += len(op)
+		case ch == '#' || ch == ':':
+			j := i + 1
+			for j < len(r) && isIdentRune(r[j]) {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("invalid expression %q: empty placeholder at position %d", expr, i)
+			}
+			kind := tokenName
+			if ch == ':' {
+				kind = tokenValue
+			}
+			tokens = append(tokens, token{kind, string(r[i:j])})
+			i = j
+		case unicode.IsDigit(ch):
+			j := i
+			for j < len(r) && unicode.IsDigit(r[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, string(r[i:j])})
+			i = j
+		case isIdentRune(ch):
+			j := i
+			for j < len(r) && isIdentRune(r[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdent, string(r[i:j])})
+			i = j
+		default:
+			return nil, fmt.Errorf("invalid expression %q: unexpected character %q", expr, ch)
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF})
+	return tokens, nil
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// parser is a recursive descent parser for DynamoDB expressions.
+type parser struct {
+	expr   string
+	tokens []token
+	pos    int
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+func newParser(expr string, names map[string]string, values map[string]types.AttributeValue) (*parser, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{expr: expr, tokens: tokens, names: names, values: values}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the (case-insensitive) keyword.
+func (p *parser) keyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	t := p.next()
+	if t.kind != kind {
+		return p.errorf("expected %q but found %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid expression %q: %s", p.expr, fmt.Sprintf(format, args...))
+}
+
+// parseCondition parses a full condition expression.
+func parseCondition(expr string, names map[string]string, values map[string]types.AttributeValue) (condition, error) {
+	p, err := newParser(expr, names, values)
+	if err != nil {
+		return nil, err
+	}
+	c, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf("unexpected %q", t.text)
+	}
+	return c, nil
+}
+
+func (p *parser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (condition, error) {
+	if p.keyword("NOT") {
+		p.next()
+		c, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{c}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (condition, error) {
+	t := p.peek()
+	if t.kind == tokenLParen {
+		p.next()
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenRParen, ")"); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+
+	// condition functions
+	if t.kind == tokenIdent && p.tokens[p.pos+1].kind == tokenLParen {
+		switch strings.ToLower(t.text) {
+		case "attribute_exists", "attribute_not_exists", "attribute_type", "begins_with", "contains":
+			return p.parseFunction()
+		}
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.keyword("BETWEEN") {
+		p.next()
+		lower, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword("AND") {
+			return nil, p.errorf("expected AND in BETWEEN")
+		}
+		p.next()
+		upper, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return betweenCondition{left, lower, upper}, nil
+	}
+
+	if p.keyword("IN") {
+		p.next()
+		if err := p.expect(tokenLParen, "("); err != nil {
+			return nil, err
+		}
+		var list []operand
+		for {
+			o, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, o)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect(tokenRParen, ")"); err != nil {
+			return nil, err
+		}
+		return inCondition{left, list}, nil
+	}
+
+	op := p.next()
+	if op.kind != tokenOperator {
+		return nil, p.errorf("expected a comparator but found %q", op.text)
+	}
+	switch op.text {
+	case "=", "<>", "<", "<=", ">", ">=":
+	default:
+		return nil, p.errorf("invalid comparator %q", op.text)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return compareCondition{op.text, left, right}, nil
+}
+
+func (p *parser) parseFunction() (condition, error) {
+	fn := strings.ToLower(p.next().text)
+	if err := p.expect(tokenLParen, "("); err != nil {
+		return nil, err
+	}
+	var args []operand
+	for {
+		o, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, o)
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect(tokenRParen, ")"); err != nil {
+		return nil, err
+	}
+
+	wantArgs := 2
+	if fn == "attribute_exists" || fn == "attribute_not_exists" {
+		wantArgs = 1
+	}
+	if len(args) != wantArgs {
+		return nil, p.errorf("%s expects %d arguments but got %d", fn, wantArgs, len(args))
+	}
+	if _, ok := args[0].(path); !ok {
+		return nil, p.errorf("the first argument to %s must be an attribute path", fn)
+	}
+	return functionCondition{fn, args}, nil
+}
+
+// parseOperand parses an attribute path, a value placeholder or a size() function.
+func (p *parser) parseOperand() (operand, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenValue:
+		p.next()
+		v, ok := p.values[t.text]
+		if !ok {
+			return nil, p.errorf("value placeholder %s is not defined in ExpressionAttributeValues", t.text)
+		}
+		return valueOperand{v}, nil
+	case t.kind == tokenIdent && strings.EqualFold(t.text, "size") && p.tokens[p.pos+1].kind == tokenLParen:
+		p.next()
+		p.next()
+		pa, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenRParen, ")"); err != nil {
+			return nil, err
+		}
+		return sizeOperand{pa}, nil
+	default:
+		return p.parsePath()
+	}
+}
+
+// parsePath parses a document path such as 'a.b[1].#c'.
+func (p *parser) parsePath() (path, error) {
+	var pa path
+	name, err := p.parsePathName()
+	if err != nil {
+		return nil, err
+	}
+	pa = append(pa, pathElement{name: name})
+
+	for {
+		switch p.peek().kind {
+		case tokenDot:
+			p.next()
+			name, err := p.parsePathName()
+			if err != nil {
+				return nil, err
+			}
+			pa = append(pa, pathElement{name: name})
+		case tokenLBracket:
+			p.next()
+			t := p.next()
+			if t.kind != tokenNumber {
+				return nil, p.errorf("expected a list index but found %q", t.text)
+			}
+			idx, err := strconv.Atoi(t.text)
+			if err != nil {
+				return nil, p.errorf("invalid list index %q", t.text)
+			}
+			if err := p.expect(tokenRBracket, "]"); err != nil {
+				return nil, err
+			}
+			pa = append(pa, pathElement{index: idx, isIndex: true})
+		default:
+			return pa, nil
+		}
+	}
+}
+
+func (p *parser) parsePathName() (string, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenIdent:
+		return t.text, nil
+	case tokenName:
+		name, ok := p.names[t.text]
+		if !ok {
+			return "", p.errorf("name placeholder %s is not defined in ExpressionAttributeNames", t.text)
+		}
+		return name, nil
+	default:
+		return "", p.errorf("expected an attribute name but found %q", t.text)
+	}
+}
+
+type pathElement struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// path is a document path to an attribute in an item.
+type path []pathElement
+
+// get returns the value at the path, or nil if it doesn't exist.
+func (pa path) get(item map[string]types.AttributeValue) types.AttributeValue {
+	var cur types.AttributeValue = &types.AttributeValueMemberM{Value: item}
+	for _, el := range pa {
+		switch v := cur.(type) {
+		case *types.AttributeValueMemberM:
+			if el.isIndex {
+				return nil
+			}
+			cur = v.Value[el.name]
+		case *types.AttributeValueMemberL:
+			if !el.isIndex || el.index >= len(v.Value) {
+				return nil
+			}
+			cur = v.Value[el.index]
+		default:
+			return nil
+		}
+		if cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
+
+func (pa path) String() string {
+	var sb strings.Builder
+	for i, el := range pa {
+		if el.isIndex {
+			fmt.Fprintf(&sb, "[%d]", el.index)
+			continue
+		}
+		if i > 0 {
+			sb.WriteString(".")
+		}
+		sb.WriteString(el.name)
+	}
+	return sb.String()
+}
+
+type operand interface {
+	resolve(item map[string]types.AttributeValue) (types.AttributeValue, error)
+}
+
+func (pa path) resolve(item map[string]types.AttributeValue) (types.AttributeValue, error) {
+	return pa.get(item), nil
+}
+
+type valueOperand struct {
+	value types.AttributeValue
+}
+
+func (v valueOperand) resolve(item map[string]types.AttributeValue) (types.AttributeValue, error) {
+	return v.value, nil
+}
+
+type sizeOperand struct {
+	path path
+}
+
+func (s sizeOperand) resolve(item map[string]types.AttributeValue) (types.AttributeValue, error) {
+	v := s.path.get(item)
+	var n int
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case *types.AttributeValueMemberS:
+		n = len(v.Value)
+	case *types.AttributeValueMemberB:
+		n = len(v.Value)
+	case *types.AttributeValueMemberL:
+		n = len(v.Value)
+	case *types.AttributeValueMemberM:
+		n = len(v.Value)
+	case *types.AttributeValueMemberSS:
+		n = len(v.Value)
+	case *types.AttributeValueMemberNS:
+		n = len(v.Value)
+	case *types.AttributeValueMemberBS:
+		n = len(v.Value)
+	default:
+		return nil, fmt.Errorf("invalid operand type for size(): %s", typeName(v))
+	}
+	return &types.AttributeValueMemberN{Value: strconv.Itoa(n)}, nil
+}
+
+// checkKeyCondition checks that a KeyConditionExpression can be used to query the key schema.
+// Like DynamoDB, it must be an equality condition on the partition key, optionally ANDed
+// with a single comparison, BETWEEN or begins_with condition on the sort key.
+func checkKeyCondition(c condition, s keySchema) error {
+	var pk, sk int
+	for _, part := range splitAnd(c) {
+		attr, ok := keyConditionAttr(part)
+		if !ok {
+			return fmt.Errorf("key conditions must be comparisons, BETWEEN or begins_with on a key attribute, combined with AND")
+		}
+		switch {
+		case attr == s.pk:
+			if cmp, ok := part.(compareCondition); !ok || cmp.op != "=" {
+				return fmt.Errorf("the partition key %s only supports the equality operator", s.pk)
+			}
+			pk++
+		case attr == s.sk && s.sk != "":
+			sk++
+		default:
+			return fmt.Errorf("%s is not a key attribute of the table or index", attr)
+		}
+	}
+	if pk != 1 {
+		return fmt.Errorf("the key condition must include exactly one equality condition on the partition key %s", s.pk)
+	}
+	if sk > 1 {
+		return fmt.Errorf("the key condition may include at most one condition on the sort key %s", s.sk)
+	}
+	return nil
+}
+
+// splitAnd returns the conditions which are ANDed together in c.
+func splitAnd(c condition) []condition {
+	if and, ok := c.(andCondition); ok {
+		return append(splitAnd(and.left), splitAnd(and.right)...)
+	}
+	return []condition{c}
+}
+
+// keyConditionAttr returns the attribute compared by a key condition,
+// which must be compared against a value rather than another attribute.
+func keyConditionAttr(c condition) (string, bool) {
+	var (
+		p      operand
+		values []operand
+	)
+	switch c := c.(type) {
+	case compareCondition:
+		if c.op == "<>" {
+			return "", false
+		}
+		p, values = c.left, []operand{c.right}
+	case betweenCondition:
+		p, values = c.value, []operand{c.lower, c.upper}
+	case functionCondition:
+		if c.fn != "begins_with" {
+			return "", false
+		}
+		p, values = c.args[0], c.args[1:]
+	default:
+		return "", false
+	}
+	pa, ok := p.(path)
+	if !ok || len(pa) != 1 || pa[0].isIndex {
+		return "", false
+	}
+	for _, v := range values {
+		if _, ok := v.(valueOperand); !ok {
+			return "", false
+		}
+	}
+	return pa[0].name, true
+}
+
+// condition is a parsed condition expression.
+type condition interface {
+	eval(item map[string]types.AttributeValue) (bool, error)
+}
+
+type andCondition struct{ left, right condition }
+
+func (c andCondition) eval(item map[string]types.AttributeValue) (bool, error) {
+	l, err := c.left.eval(item)
+	if err != nil || !l {
+		return false, err
+	}
+	return c.right.eval(item)
+}
+
+type orCondition struct{ left, right condition }
+
+func (c orCondition) eval(item map[string]types.AttributeValue) (bool, error) {
+	l, err := c.left.eval(item)
+	if err != nil || l {
+		return l, err
+	}
+	return c.right.eval(item)
+}
+
+type notCondition struct{ c condition }
+
+func (c notCondition) eval(item map[string]types.AttributeValue) (bool, error) {
+	v, err := c.c.eval(item)
+	return !v, err
+}
+
+type compareCondition struct {
+	op          string
+	left, right operand
+}
+
+func (c compareCondition) eval(item map[string]types.AttributeValue) (bool, error) {
+	l, err := c.left.resolve(item)
+	if err != nil {
+		return false, err
+	}
+	r, err := c.right.resolve(item)
+	if err != nil {
+		return false, err
+	}
+	if l == nil || r == nil {
+		// comparisons against missing attributes are always false,
+		// apart from not-equal.
+		return c.op == "<>" && !(l == nil && r == nil), nil
+	}
+
+	switch c.op {
+	case "=":
+		return equal(l, r), nil
+	case "<>":
+		return !equal(l, r), nil
+	}
+
+	cmp, ok := compare(l, r)
+	if !ok {
+		return false, nil
+	}
+	switch c.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+type betweenCondition struct {
+	value, lower, upper operand
+}
+
+func (c betweenCondition) eval(item map[string]types.AttributeValue) (bool, error) {
+	v, err := c.value.resolve(item)
+	if err != nil {
+		return false, err
+	}
+	lo, err := c.lower.resolve(item)
+	if err != nil {
+		return false, err
+	}
+	hi, err := c.upper.resolve(item)
+	if err != nil {
+		return false, err
+	}
+	if v == nil || lo == nil || hi == nil {
+		return false, nil
+	}
+	cmpLo, ok := compare(v, lo)
+	if !ok {
+		return false, nil
+	}
+	cmpHi, ok := compare(v, hi)
+	if !ok {
+		return false, nil
+	}
+	return cmpLo >= 0 && cmpHi <= 0, nil
+}
+
+type inCondition struct {
+	value operand
+	list  []operand
+}
+
+func (c inCondition) eval(item map[string]types.AttributeValue) (bool, error) {
+	v, err := c.value.resolve(item)
+	if err != nil || v == nil {
+		return false, err
+	}
+	for _, o := range c.list {
+		candidate, err := o.resolve(item)
+		if err != nil {
+			return false, err
+		}
+		if candidate != nil && equal(v, candidate) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type functionCondition struct {
+	fn   string
+	args []operand
+}
+
+func (c functionCondition) eval(item map[string]types.AttributeValue) (bool, error) {
+	v, err := c.args[0].resolve(item)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.fn {
+	case "attribute_exists":
+		return v != nil, nil
+	case "attribute_not_exists":
+		return v == nil, nil
+	}
+
+	arg, err := c.args[1].resolve(item)
+	if err != nil {
+		return false, err
+	}
+	if v == nil || arg == nil {
+		return false, nil
+	}
+
+	switch c.fn {
+	case "attribute_type":
+		s, ok := arg.(*types.AttributeValueMemberS)
+		if !ok {
+			return false, fmt.Errorf("attribute_type expects a string type argument")
+		}
+		return typeName(v) == s.Value, nil
+	case "begins_with":
+		switch v := v.(type) {
+		case *types.AttributeValueMemberS:
+			prefix, ok := arg.(*types.AttributeValueMemberS)
+			return ok && strings.HasPrefix(v.Value, prefix.Value), nil
+		case *types.AttributeValueMemberB:
+			prefix, ok := arg.(*types.AttributeValueMemberB)
+			return ok && strings.HasPrefix(string(v.Value), string(prefix.Value)), nil
+		}
+		return false, nil
+	default: // contains
+		switch v := v.(type) {
+		case *types.AttributeValueMemberS:
+			sub, ok := arg.(*types.AttributeValueMemberS)
+			return ok && strings.Contains(v.Value, sub.Value), nil
+		case *types.AttributeValueMemberB:
+			sub, ok := arg.(*types.AttributeValueMemberB)
+			return ok && strings.Contains(string(v.Value), string(sub.Value)), nil
+		case *types.AttributeValueMemberSS:
+			sub, ok := arg.(*types.AttributeValueMemberS)
+			return ok && containsString(v.Value, sub.Value), nil
+		case *types.AttributeValueMemberNS:
+			sub, ok := arg.(*types.AttributeValueMemberN)
+			if !ok {
+				return false, nil
+			}
+			for _, n := range v.Value {
+				if equal(&types.AttributeValueMemberN{Value: n}, sub) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case *types.AttributeValueMemberBS:
+			sub, ok := arg.(*types.AttributeValueMemberB)
+			if !ok {
+				return false, nil
+			}
+			for _, b := range v.Value {
+				if string(b) == string(sub.Value) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case *types.AttributeValueMemberL:
+			for _, el := range v.Value {
+				if equal(el, arg) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return false, nil
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseProjection parses a ProjectionExpression, which is a comma-separated list of document paths.
+func parseProjection(expr string, names map[string]string) ([]path, error) {
+	p, err := newParser(expr, names, nil)
+	if err != nil {
+		return nil, err
+	}
+	var paths []path
+	for {
+		pa, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		for _, el := range pa {
+			if el.isIndex {
+				return nil, p.errorf("list elements in projections are not supported by ddbmem")
+			}
+		}
+		paths = append(paths, pa)
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.next()
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf("unexpected %q", t.text)
+	}
+	return paths, nil
+}
+
+// project returns a copy of the item containing only the attributes at the given paths.
+func project(item map[string]types.AttributeValue, paths []path) map[string]types.AttributeValue {
+	out := map[string]types.AttributeValue{}
+	for _, pa := range paths {
+		v := pa.get(item)
+		if v == nil {
+			continue
+		}
+		m := out
+		for _, el := range pa[:len(pa)-1] {
+			child, ok := m[el.name].(*types.AttributeValueMemberM)
+			if !ok {
+				child = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}}
+				m[el.name] = child
+			}
+			m = child.Value
+		}
+		m[pa[len(pa)-1].name] = copyValue(v)
+	}
+	return out
+}
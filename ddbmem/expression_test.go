@@ -0,0 +1,85 @@
+package ddbmem
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCondition(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"PK":     &types.AttributeValueMemberS{Value: "THING"},
+		"SK":     &types.AttributeValueMemberS{Value: "THING#2"},
+		"Count":  &types.AttributeValueMemberN{Value: "10"},
+		"Status": &types.AttributeValueMemberS{Value: "ACTIVE"},
+		"Tags":   &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"Nested": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"List": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+				&types.AttributeValueMemberS{Value: "first"},
+			}},
+		}},
+	}
+	names := map[string]string{"#status": "Status", "#count": "Count"}
+	values := map[string]types.AttributeValue{
+		":pk":     &types.AttributeValueMemberS{Value: "THING"},
+		":prefix": &types.AttributeValueMemberS{Value: "THING#"},
+		":active": &types.AttributeValueMemberS{Value: "ACTIVE"},
+		":other":  &types.AttributeValueMemberS{Value: "OTHER"},
+		":five":   &types.AttributeValueMemberN{Value: "5"},
+		":ten":    &types.AttributeValueMemberN{Value: "10.0"},
+		":a":      &types.AttributeValueMemberS{Value: "a"},
+		":first":  &types.AttributeValueMemberS{Value: "first"},
+		":type":   &types.AttributeValueMemberS{Value: "N"},
+		":two":    &types.AttributeValueMemberN{Value: "2"},
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "equal", expr: "PK = :pk", want: true},
+		{name: "key condition", expr: "PK = :pk AND begins_with(SK, :prefix)", want: true},
+		{name: "lowercase keywords", expr: "PK = :pk and begins_with(SK, :prefix)", want: true},
+		{name: "name placeholder", expr: "#status = :active", want: true},
+		{name: "not equal", expr: "#status <> :other", want: true},
+		{name: "numeric comparison", expr: "#count > :five", want: true},
+		{name: "numbers compare by value", expr: "#count = :ten", want: true},
+		{name: "between", expr: "#count BETWEEN :five AND :ten", want: true},
+		{name: "in", expr: "#status IN (:other, :active)", want: true},
+		{name: "or", expr: "#status = :other OR #count >= :ten", want: true},
+		{name: "not", expr: "NOT #status = :active", want: false},
+		{name: "parentheses", expr: "(#status = :other OR #status = :active) AND #count < :five", want: false},
+		{name: "attribute exists", expr: "attribute_exists(PK)", want: true},
+		{name: "attribute not exists", expr: "attribute_not_exists(Missing)", want: true},
+		{name: "attribute type", expr: "attribute_type(#count, :type)", want: true},
+		{name: "contains set", expr: "contains(Tags, :a)", want: true},
+		{name: "document path", expr: "Nested.List[0] = :first", want: true},
+		{name: "size", expr: "size(Tags) = :two", want: true},
+		{name: "missing attribute", expr: "Missing = :pk", want: false},
+		{name: "type mismatch", expr: "#count > :pk", want: false},
+		{name: "undefined value", expr: "PK = :missing", wantErr: true},
+		{name: "undefined name", expr: "#missing = :pk", wantErr: true},
+		{name: "trailing tokens", expr: "PK = :pk :pk", wantErr: true},
+		{name: "invalid comparator", expr: "PK + :pk", wantErr: true},
+		{name: "wrong function arguments", expr: "begins_with(SK)", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := parseCondition(tt.expr, names, values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCondition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := c.eval(item)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
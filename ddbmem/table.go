@@ -0,0 +1,715 @@
+package ddbmem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/common-fate/ddb"
+)
+
+var _ ddb.DynamoDBAPI = &Table{}
+
+// keySchema is the partition and sort key attribute names of a table or index.
+// The sort key is empty if the table or index doesn't have one.
+type keySchema struct {
+	pk string
+	sk string
+}
+
+// attrs returns the key attribute names.
+func (s keySchema) attrs() []string {
+	if s.sk == "" {
+		return []string{s.pk}
+	}
+	return []string{s.pk, s.sk}
+}
+
+// contains returns true if the item has all of the key attributes.
+func (s keySchema) contains(item map[string]types.AttributeValue) bool {
+	for _, attr := range s.attrs() {
+		if item[attr] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Table is an in-memory DynamoDB table. It implements the ddb.DynamoDBAPI
+// interface, so it can be used in place of a real DynamoDB client.
+// It is goroutine-safe.
+type Table struct {
+	mu      sync.Mutex
+	primary keySchema
+	indexes map[string]keySchema
+	// items are stored by their primary key.
+	items map[string]map[string]types.AttributeValue
+}
+
+// NewTable creates a new empty Table.
+// The table uses the key naming conventions of the ddb package:
+// a primary key of PK and SK, and global secondary indexes GSI1 to GSI4.
+func NewTable() *Table {
+	return NewTableFromSchema(ddb.DefaultTableSchema())
+}
+
+// NewTableFromSchema creates a new empty Table with the key attributes
+// and indexes declared in the schema.
+func NewTableFromSchema(schema ddb.TableSchema) *Table {
+	t := &Table{
+		items: map[string]map[string]types.AttributeValue{},
+	}
+	t.setSchema(schema)
+	return t
+}
+
+func (t *Table) setSchema(schema ddb.TableSchema) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.primary = keySchema{pk: schema.PartitionKey, sk: schema.SortKey}
+	t.indexes = map[string]keySchema{}
+	for _, idx := range schema.Indexes {
+		t.indexes[idx.Name] = keySchema{pk: idx.PartitionKey, sk: idx.SortKey}
+	}
+}
+
+// validationError returns an error matching the one returned by DynamoDB
+// for invalid requests.
+func validationError(format string, args ...interface{}) error {
+	return &smithy.GenericAPIError{
+		Code:    "ValidationException",
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// checkCondition evaluates a ConditionExpression against the existing item,
+// which is nil if the item doesn't exist. It returns a
+// ConditionalCheckFailedException if the condition isn't met.
+func checkCondition(expr *string, names map[string]string, values map[string]types.AttributeValue, existing map[string]types.AttributeValue) error {
+	if expr == nil {
+		return nil
+	}
+	c, err := parseCondition(*expr, names, values)
+	if err != nil {
+		return validationError("Invalid ConditionExpression: %s", err)
+	}
+	if existing == nil {
+		existing = map[string]types.AttributeValue{}
+	}
+	ok, err := c.eval(existing)
+	if err != nil {
+		return validationError("Invalid ConditionExpression: %s", err)
+	}
+	if !ok {
+		return &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	return nil
+}
+
+// cancellationReason returns the transaction cancellation reason for a write.
+func cancellationReason(err error) types.CancellationReason {
+	if err == nil {
+		return types.CancellationReason{Code: aws.String("None")}
+	}
+	return types.CancellationReason{
+		Code:    aws.String("ConditionalCheckFailed"),
+		Message: aws.String("The conditional request failed"),
+	}
+}
+
+// keyString returns a string representation of a key attribute which
+// can be used for map lookups.
+func keyString(v types.AttributeValue) string {
+	switch v := v.(type) {
+	case *types.AttributeValueMemberS:
+		return "S:" + v.Value
+	case *types.AttributeValueMemberN:
+		if f, ok := parseNumber(v.Value); ok {
+			return "N:" + f.Text('g', -1)
+		}
+		return "N:" + v.Value
+	case *types.AttributeValueMemberB:
+		return "B:" + string(v.Value)
+	}
+	return ""
+}
+
+// primaryKey validates the primary key attributes of an item or key
+// and returns the string used to store the item.
+func (t *Table) primaryKey(item map[string]types.AttributeValue) (string, error) {
+	pk := keyString(item[t.primary.pk])
+	if pk == "" {
+		return "", validationError("One of the required keys was not given a value: missing or invalid %s", t.primary.pk)
+	}
+	if t.primary.sk == "" {
+		return pk, nil
+	}
+	sk := keyString(item[t.primary.sk])
+	if sk == "" {
+		return "", validationError("One of the required keys was not given a value: missing or invalid %s", t.primary.sk)
+	}
+	return pk + "|" + sk, nil
+}
+
+// keyOf returns the primary key attributes of an item.
+func (t *Table) keyOf(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	key := map[string]types.AttributeValue{}
+	for _, attr := range t.primary.attrs() {
+		key[attr] = copyValue(item[attr])
+	}
+	return key
+}
+
+// schemaFor returns the key schema of the table or the named index.
+func (t *Table) schemaFor(index *string) (keySchema, error) {
+	if index == nil {
+		return t.primary, nil
+	}
+	s, ok := t.indexes[*index]
+	if !ok {
+		return keySchema{}, validationError("The table does not have the specified index: %s", *index)
+	}
+	return s, nil
+}
+
+// compareAttr compares two key attributes. Missing attributes sort first.
+func compareAttr(a, b types.AttributeValue) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	cmp, _ := compare(a, b)
+	return cmp
+}
+
+// compareKeys orders items by their sort key within a key schema,
+// using the table's primary key as a tiebreaker.
+func (t *Table) compareKeys(s keySchema, a, b map[string]types.AttributeValue) int {
+	for _, attr := range []string{s.pk, s.sk, t.primary.pk, t.primary.sk} {
+		if cmp := compareAttr(a[attr], b[attr]); cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+// lastEvaluatedKey returns the key attributes of an item for use as
+// a pagination cursor.
+func (t *Table) lastEvaluatedKey(s keySchema, item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	key := t.keyOf(item)
+	for _, attr := range s.attrs() {
+		key[attr] = copyValue(item[attr])
+	}
+	return key
+}
+
+// Query finds items based on primary key values, on either the table
+// or one of its global secondary indexes.
+func (t *Table) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, err := t.schemaFor(params.IndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.KeyConditionExpression == nil {
+		return nil, validationError("Either the KeyConditions or KeyConditionExpression parameter must be specified in the request.")
+	}
+	keyCond, err := parseCondition(*params.KeyConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, validationError("Invalid KeyConditionExpression: %s", err)
+	}
+	if err := checkKeyCondition(keyCond, s); err != nil {
+		return nil, validationError("Invalid KeyConditionExpression: %s", err)
+	}
+
+	var filter condition
+	if params.FilterExpression != nil {
+		filter, err = parseCondition(*params.FilterExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+		if err != nil {
+			return nil, validationError("Invalid FilterExpression: %s", err)
+		}
+	}
+
+	var matches []map[string]types.AttributeValue
+	for _, item := range t.items {
+		// items without the index keys aren't projected into the index.
+		if !s.contains(item) {
+			continue
+		}
+		ok, err := keyCond.eval(item)
+		if err != nil {
+			return nil, validationError("Invalid KeyConditionExpression: %s", err)
+		}
+		if ok {
+			matches = append(matches, item)
+		}
+	}
+
+	forward := params.ScanIndexForward == nil || *params.ScanIndexForward
+	sort.Slice(matches, func(i, j int) bool {
+		cmp := t.compareKeys(s, matches[i], matches[j])
+		if forward {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+
+	res, err := t.readPage(s, matches, forward, params.ExclusiveStartKey, params.Limit, filter, params.Select)
+	if err != nil {
+		return nil, err
+	}
+	if err := projectItems(res.items, params.ProjectionExpression, params.ExpressionAttributeNames); err != nil {
+		return nil, err
+	}
+	return &dynamodb.QueryOutput{
+		Items:            res.items,
+		Count:            res.count,
+		ScannedCount:     res.scannedCount,
+		LastEvaluatedKey: res.lastEvaluatedKey,
+	}, nil
+}
+
+// page is a page of results read by Query or Scan.
+type page struct {
+	items            []map[string]types.AttributeValue
+	count            int32
+	scannedCount     int32
+	lastEvaluatedKey map[string]types.AttributeValue
+}
+
+// readPage reads a page of results from items sorted within the key schema.
+// It starts after the ExclusiveStartKey and evaluates at most 'limit' items,
+// then applies the filter.
+func (t *Table) readPage(s keySchema, sorted []map[string]types.AttributeValue, forward bool, startKey map[string]types.AttributeValue, limit *int32, filter condition, sel types.Select) (*page, error) {
+	// skip to the item following the ExclusiveStartKey.
+	if startKey != nil {
+		start := len(sorted)
+		for i, item := range sorted {
+			cmp := t.compareKeys(s, item, startKey)
+			if (forward && cmp > 0) || (!forward && cmp < 0) {
+				start = i
+				break
+			}
+		}
+		sorted = sorted[start:]
+	}
+
+	res := &page{}
+	evaluated := sorted
+	if limit != nil && int(*limit) < len(sorted) {
+		evaluated = sorted[:*limit]
+		res.lastEvaluatedKey = t.lastEvaluatedKey(s, evaluated[len(evaluated)-1])
+	}
+
+	for _, item := range evaluated {
+		if filter != nil {
+			ok, err := filter.eval(item)
+			if err != nil {
+				return nil, validationError("Invalid FilterExpression: %s", err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		res.count++
+		if sel != types.SelectCount {
+			res.items = append(res.items, copyItem(item))
+		}
+	}
+	res.scannedCount = int32(len(evaluated))
+
+	return res, nil
+}
+
+// projectItems applies a ProjectionExpression to the items read by Query or Scan.
+func projectItems(items []map[string]types.AttributeValue, expr *string, names map[string]string) error {
+	if expr == nil {
+		return nil
+	}
+	paths, err := parseProjection(*expr, names)
+	if err != nil {
+		return validationError("Invalid ProjectionExpression: %s", err)
+	}
+	for i, item := range items {
+		items[i] = project(item, paths)
+	}
+	return nil
+}
+
+// Scan reads every item in the table or one of its global secondary indexes.
+// Items are split into segments by hashing their partition key.
+func (t *Table) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, err := t.schemaFor(params.IndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	var segment, total int32
+	if params.TotalSegments != nil {
+		total = *params.TotalSegments
+		if params.Segment != nil {
+			segment = *params.Segment
+		}
+		if total < 1 || segment < 0 || segment >= total {
+			return nil, validationError("Segment must be less than TotalSegments")
+		}
+	}
+
+	var filter condition
+	if params.FilterExpression != nil {
+		filter, err = parseCondition(*params.FilterExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+		if err != nil {
+			return nil, validationError("Invalid FilterExpression: %s", err)
+		}
+	}
+
+	var matches []map[string]types.AttributeValue
+	for _, item := range t.items {
+		if !s.contains(item) {
+			continue
+		}
+		if total > 0 {
+			h := fnv.New32a()
+			h.Write([]byte(keyString(item[s.pk])))
+			if int32(h.Sum32()%uint32(total)) != segment {
+				continue
+			}
+		}
+		matches = append(matches, item)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return t.compareKeys(s, matches[i], matches[j]) < 0
+	})
+
+	res, err := t.readPage(s, matches, true, params.ExclusiveStartKey, params.Limit, filter, params.Select)
+	if err != nil {
+		return nil, err
+	}
+	if err := projectItems(res.items, params.ProjectionExpression, params.ExpressionAttributeNames); err != nil {
+		return nil, err
+	}
+	return &dynamodb.ScanOutput{
+		Items:            res.items,
+		Count:            res.count,
+		ScannedCount:     res.scannedCount,
+		LastEvaluatedKey: res.lastEvaluatedKey,
+	}, nil
+}
+
+// GetItem returns the item with the given primary key.
+func (t *Table) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key, err := t.primaryKey(params.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.GetItemOutput{Item: copyItem(t.items[key])}, nil
+}
+
+// BatchGetItem returns the items with the given keys.
+// All keys are processed, so UnprocessedKeys is always empty.
+func (t *Table) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]types.AttributeValue{},
+	}
+	for table, ka := range params.RequestItems {
+		if len(ka.Keys) > 100 {
+			return nil, validationError("Too many items requested for the BatchGetItem call")
+		}
+		seen := map[string]bool{}
+		for _, k := range ka.Keys {
+			key, err := t.primaryKey(k)
+			if err != nil {
+				return nil, err
+			}
+			if seen[key] {
+				return nil, validationError("Provided list of item keys contains duplicates")
+			}
+			seen[key] = true
+			if item, ok := t.items[key]; ok {
+				out.Responses[table] = append(out.Responses[table], copyItem(item))
+			}
+		}
+	}
+	return out, nil
+}
+
+// PutItem creates a new item, or replaces an existing item.
+func (t *Table) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key, err := t.primaryKey(params.Item)
+	if err != nil {
+		return nil, err
+	}
+	err = checkCondition(params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, t.items[key])
+	if err != nil {
+		return nil, err
+	}
+	out := &dynamodb.PutItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		out.Attributes = copyItem(t.items[key])
+	}
+	t.items[key] = copyItem(params.Item)
+	return out, nil
+}
+
+// DeleteItem deletes a single item by its primary key.
+func (t *Table) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key, err := t.primaryKey(params.Key)
+	if err != nil {
+		return nil, err
+	}
+	err = checkCondition(params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, t.items[key])
+	if err != nil {
+		return nil, err
+	}
+	out := &dynamodb.DeleteItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		out.Attributes = copyItem(t.items[key])
+	}
+	delete(t.items, key)
+	return out, nil
+}
+
+// applyUpdate parses an update expression and returns the item
+// stored under key with the update applied. If the item doesn't exist,
+// the update is applied to a new item containing the key attributes.
+func (t *Table) applyUpdate(key string, keyAttrs map[string]types.AttributeValue, expr *string, names map[string]string, values map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	if expr == nil {
+		return nil, validationError("UpdateExpression must be provided")
+	}
+	u, err := parseUpdate(*expr, names, values)
+	if err != nil {
+		return nil, validationError("Invalid UpdateExpression: %s", err)
+	}
+	for _, pa := range u.paths() {
+		if containsString(t.primary.attrs(), pa[0].name) {
+			return nil, validationError("Cannot update attribute %s. This attribute is part of the key", pa[0].name)
+		}
+	}
+
+	item := t.items[key]
+	if item == nil {
+		item = t.keyOf(keyAttrs)
+	}
+	updated, err := u.apply(item)
+	if err != nil {
+		return nil, validationError("Invalid UpdateExpression: %s", err)
+	}
+	return updated, nil
+}
+
+// UpdateItem edits an existing item's attributes, or adds a new item
+// if it doesn't already exist.
+func (t *Table) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key, err := t.primaryKey(params.Key)
+	if err != nil {
+		return nil, err
+	}
+	existing := t.items[key]
+	err = checkCondition(params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, existing)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := t.applyUpdate(key, params.Key, params.UpdateExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, err
+	}
+	t.items[key] = updated
+
+	out := &dynamodb.UpdateItemOutput{}
+	switch params.ReturnValues {
+	case types.ReturnValueAllOld:
+		out.Attributes = copyItem(existing)
+	case types.ReturnValueAllNew:
+		out.Attributes = copyItem(updated)
+	case types.ReturnValueUpdatedOld, types.ReturnValueUpdatedNew:
+		src := existing
+		if params.ReturnValues == types.ReturnValueUpdatedNew {
+			src = updated
+		}
+		// the updated attributes are approximated by the top level
+		// attributes referenced in the update expression.
+		u, _ := parseUpdate(*params.UpdateExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+		for _, pa := range u.paths() {
+			if v, ok := src[pa[0].name]; ok {
+				if out.Attributes == nil {
+					out.Attributes = map[string]types.AttributeValue{}
+				}
+				out.Attributes[pa[0].name] = copyValue(v)
+			}
+		}
+	}
+	return out, nil
+}
+
+// BatchWriteItem puts or deletes multiple items.
+// All writes are always processed, so UnprocessedItems is always empty.
+func (t *Table) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, requests := range params.RequestItems {
+		if len(requests) > 25 {
+			return nil, validationError("Too many items requested for the BatchWriteItem call")
+		}
+		for _, wr := range requests {
+			switch {
+			case wr.PutRequest != nil:
+				key, err := t.primaryKey(wr.PutRequest.Item)
+				if err != nil {
+					return nil, err
+				}
+				t.items[key] = copyItem(wr.PutRequest.Item)
+			case wr.DeleteRequest != nil:
+				key, err := t.primaryKey(wr.DeleteRequest.Key)
+				if err != nil {
+					return nil, err
+				}
+				delete(t.items, key)
+			}
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+// TransactWriteItems applies a set of writes atomically.
+func (t *Table) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(params.TransactItems) == 0 {
+		return nil, validationError("1 validation error detected: Value null at 'transactItems' failed to satisfy constraint: Member must not be null")
+	}
+
+	// transactWrite is a write within the transaction, normalised
+	// from the different operation types.
+	type transactWrite struct {
+		key       string
+		condition *string
+		names     map[string]string
+		values    map[string]types.AttributeValue
+		// result is the item after the write, or nil if the item is deleted.
+		result map[string]types.AttributeValue
+		// check is true for condition checks, which don't write to the item.
+		check bool
+	}
+
+	// validate the whole transaction before applying any writes.
+	seen := map[string]bool{}
+	writes := make([]transactWrite, len(params.TransactItems))
+	for i, ti := range params.TransactItems {
+		var (
+			w   transactWrite
+			err error
+		)
+		switch {
+		case ti.Put != nil:
+			w = transactWrite{condition: ti.Put.ConditionExpression, names: ti.Put.ExpressionAttributeNames, values: ti.Put.ExpressionAttributeValues, result: ti.Put.Item}
+			w.key, err = t.primaryKey(ti.Put.Item)
+		case ti.Delete != nil:
+			w = transactWrite{condition: ti.Delete.ConditionExpression, names: ti.Delete.ExpressionAttributeNames, values: ti.Delete.ExpressionAttributeValues}
+			w.key, err = t.primaryKey(ti.Delete.Key)
+		case ti.Update != nil:
+			w = transactWrite{condition: ti.Update.ConditionExpression, names: ti.Update.ExpressionAttributeNames, values: ti.Update.ExpressionAttributeValues}
+			w.key, err = t.primaryKey(ti.Update.Key)
+			if err == nil {
+				w.result, err = t.applyUpdate(w.key, ti.Update.Key, ti.Update.UpdateExpression, w.names, w.values)
+			}
+		case ti.ConditionCheck != nil:
+			if ti.ConditionCheck.ConditionExpression == nil {
+				return nil, validationError("The ConditionCheck must contain a ConditionExpression")
+			}
+			w = transactWrite{condition: ti.ConditionCheck.ConditionExpression, names: ti.ConditionCheck.ExpressionAttributeNames, values: ti.ConditionCheck.ExpressionAttributeValues, check: true}
+			w.key, err = t.primaryKey(ti.ConditionCheck.Key)
+		default:
+			err = validationError("TransactItems can only contain one of Check, Put, Update or Delete")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if seen[w.key] {
+			return nil, validationError("Transaction request cannot include multiple operations on one item")
+		}
+		seen[w.key] = true
+		writes[i] = w
+	}
+
+	// check the conditions of each write. If any fail, the whole transaction is cancelled.
+	reasons := make([]types.CancellationReason, len(writes))
+	var cancelled bool
+	for i, w := range writes {
+		err := checkCondition(w.condition, w.names, w.values, t.items[w.key])
+		reasons[i] = cancellationReason(err)
+		if err != nil {
+			var ccf *types.ConditionalCheckFailedException
+			if !errors.As(err, &ccf) {
+				return nil, err
+			}
+			cancelled = true
+		}
+	}
+	if cancelled {
+		return nil, &types.TransactionCanceledException{
+			Message:             aws.String("Transaction cancelled, please refer cancellation reasons for specific reasons"),
+			CancellationReasons: reasons,
+		}
+	}
+
+	for _, w := range writes {
+		if w.check {
+			continue
+		}
+		if w.result == nil {
+			delete(t.items, w.key)
+			continue
+		}
+		t.items[w.key] = copyItem(w.result)
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+// Len returns the number of items in the table.
+func (t *Table) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.items)
+}
+
+// Reset removes all items from the table.
+func (t *Table) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.items = map[string]map[string]types.AttributeValue{}
+}
@@ -0,0 +1,1093 @@
+package ddbmem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/common-fate/ddb"
+	"github.com/stretchr/testify/assert"
+)
+
+type thing struct {
+	Type  string
+	ID    string
+	Color string
+}
+
+func (t thing) DDBKeys() (ddb.Keys, error) {
+	return ddb.Keys{PK: t.Type, SK: t.ID, GSI1PK: t.Color, GSI1SK: t.ID}, nil
+}
+
+type listThings struct {
+	Type   string
+	Color  string
+	Result []thing `ddb:"result"`
+}
+
+func (l *listThings) BuildQuery() (*dynamodb.QueryInput, error) {
+	qi := dynamodb.QueryInput{
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: l.Type},
+		},
+	}
+	if l.Color != "" {
+		qi.FilterExpression = aws.String("Color = :color")
+		qi.ExpressionAttributeValues[":color"] = &types.AttributeValueMemberS{Value: l.Color}
+	}
+	return &qi, nil
+}
+
+type listThingsByColor struct {
+	Color  string
+	Result []thing `ddb:"result"`
+}
+
+func (l *listThingsByColor) BuildQuery() (*dynamodb.QueryInput, error) {
+	qi := dynamodb.QueryInput{
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: l.Color},
+		},
+	}
+	return &qi, nil
+}
+
+var fixtures = []thing{
+	{Type: "apple", ID: "1", Color: "red"},
+	{Type: "apple", ID: "2", Color: "green"},
+	{Type: "apple", ID: "3", Color: "red"},
+	{Type: "pear", ID: "4", Color: "green"},
+}
+
+func newTestClient(t *testing.T) *ddb.Client {
+	ctx := context.Background()
+	c, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fixtures {
+		err = c.Put(ctx, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		give ddb.QueryBuilder
+		opts []func(*ddb.QueryOpts)
+		want []thing
+	}{
+		{
+			name: "partition",
+			give: &listThings{Type: "apple"},
+			want: fixtures[0:3],
+		},
+		{
+			name: "filter",
+			give: &listThings{Type: "apple", Color: "red"},
+			want: []thing{fixtures[0], fixtures[2]},
+		},
+		{
+			name: "gsi",
+			give: &listThingsByColor{Color: "green"},
+			want: []thing{fixtures[1], fixtures[3]},
+		},
+		{
+			name: "limit",
+			give: &listThings{Type: "apple"},
+			opts: []func(*ddb.QueryOpts){ddb.Limit(2)},
+			want: fixtures[0:2],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t)
+			_, err := c.Query(context.Background(), tt.give, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []thing
+			switch q := tt.give.(type) {
+			case *listThings:
+				got = q.Result
+			case *listThingsByColor:
+				got = q.Result
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQueryInvalidKeyCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+		index     *string
+	}{
+		{name: "non-key attribute", condition: "PK = :a AND Color = :c"},
+		{name: "or", condition: "Color = :c OR SK = :c"},
+		{name: "not", condition: "NOT PK = :a"},
+		{name: "missing partition key", condition: "SK = :s"},
+		{name: "partition key comparison", condition: "PK > :a"},
+		{name: "partition key begins_with", condition: "begins_with(PK, :a)"},
+		{name: "two sort key conditions", condition: "PK = :a AND SK > :s AND SK < :s"},
+		{name: "not equal", condition: "PK = :a AND SK <> :s"},
+		{name: "compare attributes", condition: "PK = :a AND SK = PK"},
+		{name: "table key on index", condition: "PK = :a", index: aws.String("GSI1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tbl := NewTable()
+			_, err := tbl.Query(context.Background(), &dynamodb.QueryInput{
+				IndexName:              tt.index,
+				KeyConditionExpression: &tt.condition,
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":a": &types.AttributeValueMemberS{Value: "apple"},
+					":c": &types.AttributeValueMemberS{Value: "red"},
+					":s": &types.AttributeValueMemberS{Value: "1"},
+				},
+			})
+			var apiErr smithy.APIError
+			if assert.ErrorAs(t, err, &apiErr) {
+				assert.Equal(t, "ValidationException", apiErr.ErrorCode())
+			}
+		})
+	}
+}
+
+func TestQueryPagination(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	q := &listThings{Type: "apple"}
+	res, err := c.Query(ctx, q, ddb.Limit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtures[0:2], q.Result)
+	assert.NotEmpty(t, res.NextPage)
+
+	res, err = c.Query(ctx, q, ddb.Limit(2), ddb.Page(res.NextPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtures[2:3], q.Result)
+	assert.Empty(t, res.NextPage)
+
+	all := &listThings{Type: "apple"}
+	err = c.All(ctx, all, ddb.Limit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtures[0:3], all.Result)
+}
+
+func TestGetAndDelete(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	var got thing
+	_, err := c.Get(ctx, ddb.GetKey{PK: "apple", SK: "1"}, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtures[0], got)
+
+	err = c.Delete(ctx, fixtures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Get(ctx, ddb.GetKey{PK: "apple", SK: "1"}, &got)
+	assert.Equal(t, ddb.ErrNoItems, err)
+
+	err = c.DeleteBatch(ctx, fixtures[1], fixtures[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := &listThings{Type: "apple"}
+	_, err = c.Query(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, q.Result)
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	tx := c.NewTransaction()
+	tx.Put(thing{Type: "apple", ID: "5", Color: "red"})
+	tx.Delete(fixtures[0])
+	err := tx.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := &listThings{Type: "apple"}
+	_, err = c.Query(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{fixtures[1], fixtures[2], {Type: "apple", ID: "5", Color: "red"}}, q.Result)
+
+	// a transaction can't include multiple operations on the same item.
+	tx = c.NewTransaction()
+	tx.Put(fixtures[1])
+	tx.Delete(fixtures[1])
+	err = tx.Execute(ctx)
+	assert.Error(t, err)
+}
+
+func TestTransactionConditions(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	// the condition check fails because the parent item doesn't exist,
+	// so none of the writes are made.
+	tx := c.NewTransaction()
+	tx.ConditionCheck(ddb.GetKey{PK: "tree", SK: "1"}, ddb.IfExists())
+	tx.Put(thing{Type: "apple", ID: "5", Color: "red"})
+	err := tx.Execute(ctx)
+	assert.ErrorIs(t, err, ddb.ErrConditionFailed)
+	var te *ddb.TransactionError
+	if assert.ErrorAs(t, err, &te) {
+		assert.Len(t, te.Cancellations, 1)
+		assert.Equal(t, &ddb.GetKey{PK: "tree", SK: "1"}, te.Cancellations[0].Item.ConditionCheck)
+	}
+
+	err = c.Put(ctx, thing{Type: "tree", ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx = c.NewTransaction()
+	tx.ConditionCheck(ddb.GetKey{PK: "tree", SK: "1"}, ddb.IfExists())
+	tx.Put(thing{Type: "apple", ID: "5", Color: "red"}, ddb.IfNotExists())
+	tx.Delete(fixtures[0], ddb.Condition("Color = :color", nil, map[string]types.AttributeValue{
+		":color": &types.AttributeValueMemberS{Value: "red"},
+	}))
+	tx.Update(ddb.GetKey{PK: "apple", SK: "2"}, ddb.NewUpdate().Set("Color", "yellow"), ddb.IfExists())
+	err = tx.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := &listThings{Type: "apple"}
+	_, err = c.Query(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{Type: "apple", ID: "2", Color: "yellow"}, fixtures[2], {Type: "apple", ID: "5", Color: "red"}}, q.Result)
+
+	// a condition check must have a condition.
+	tx = c.NewTransaction()
+	tx.ConditionCheck(ddb.GetKey{PK: "tree", SK: "1"})
+	err = tx.Execute(ctx)
+	assert.Error(t, err)
+}
+
+func TestConditionalWrites(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	err := c.Put(ctx, fixtures[0], ddb.IfNotExists())
+	assert.Equal(t, ddb.ErrConditionFailed, err)
+
+	err = c.Put(ctx, thing{Type: "apple", ID: "6"}, ddb.IfNotExists())
+	assert.NoError(t, err)
+
+	err = c.Delete(ctx, thing{Type: "apple", ID: "7"}, ddb.IfExists())
+	assert.Equal(t, ddb.ErrConditionFailed, err)
+
+	isGreen := ddb.Condition("Color = :color", nil, map[string]types.AttributeValue{
+		":color": &types.AttributeValueMemberS{Value: "green"},
+	})
+
+	err = c.Delete(ctx, fixtures[0], isGreen)
+	assert.Equal(t, ddb.ErrConditionFailed, err)
+
+	err = c.Delete(ctx, fixtures[1], isGreen)
+	assert.NoError(t, err)
+}
+
+type account struct {
+	ID      string
+	Balance int
+	Version int `ddb:"version"`
+}
+
+func (a *account) DDBKeys() (ddb.Keys, error) {
+	return ddb.Keys{PK: "ACCOUNT", SK: a.ID}, nil
+}
+
+func TestOptimisticLocking(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &account{ID: "1", Balance: 10}
+	err = c.Put(ctx, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, a.Version)
+
+	// a stale copy of the account can't be written.
+	stale := &account{ID: "1", Balance: 20}
+	err = c.Put(ctx, stale)
+	assert.Equal(t, ddb.ErrVersionConflict, err)
+
+	a.Balance = 30
+	err = c.Put(ctx, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, a.Version)
+
+	var got account
+	_, err = c.Get(ctx, ddb.GetKey{PK: "ACCOUNT", SK: "1"}, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, account{ID: "1", Balance: 30, Version: 2}, got)
+
+	// transactions also use optimistic locking.
+	tx := c.NewTransaction()
+	tx.Put(&account{ID: "2"})
+	tx.Put(&account{ID: "1", Version: 1})
+	err = tx.Execute(ctx)
+	assert.ErrorIs(t, err, ddb.ErrVersionConflict)
+	assert.NotErrorIs(t, err, ddb.ErrConditionFailed)
+	var te *ddb.TransactionError
+	if assert.ErrorAs(t, err, &te) {
+		assert.Len(t, te.Cancellations, 1)
+		assert.Equal(t, 1, te.Cancellations[0].Index)
+		assert.True(t, te.Cancellations[0].VersionConflict)
+	}
+
+	tx = c.NewTransaction()
+	tx.Put(a)
+	err = tx.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, a.Version)
+
+	err = c.Delete(ctx, &account{ID: "1", Version: 2})
+	assert.Equal(t, ddb.ErrVersionConflict, err)
+
+	// if the write has other conditions, it isn't known whether the version check failed.
+	err = c.Put(ctx, &account{ID: "1", Version: 3}, ddb.IfNotExists())
+	assert.Equal(t, ddb.ErrConditionFailed, err)
+
+	err = c.Delete(ctx, &account{ID: "1", Version: 2}, ddb.IfExists())
+	assert.Equal(t, ddb.ErrConditionFailed, err)
+
+	err = c.Delete(ctx, a)
+	assert.NoError(t, err)
+}
+
+type counter struct {
+	ID    string
+	Count int
+	Tags  []string `dynamodbav:",stringset,omitempty"`
+}
+
+func (c counter) DDBKeys() (ddb.Keys, error) {
+	return ddb.Keys{PK: "COUNTER", SK: c.ID}, nil
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := ddb.GetKey{PK: "COUNTER", SK: "1"}
+
+	// updating an item which doesn't exist creates it.
+	var got counter
+	_, err = c.Update(ctx, key, ddb.NewUpdate().Set("ID", "1").Add("Count", 1), ddb.ReturnNew(&got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, counter{ID: "1", Count: 1}, got)
+
+	var old counter
+	_, err = c.Update(ctx, key, ddb.NewUpdate().Add("Count", 2).Add("Tags", &types.AttributeValueMemberSS{Value: []string{"a", "b"}}), ddb.ReturnOld(&old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, counter{ID: "1", Count: 1}, old)
+
+	_, err = c.Update(ctx, key, ddb.NewUpdate().Delete("Tags", &types.AttributeValueMemberSS{Value: []string{"a"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Get(ctx, key, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, counter{ID: "1", Count: 3, Tags: []string{"b"}}, got)
+
+	_, err = c.Update(ctx, ddb.GetKey{PK: "COUNTER", SK: "2"}, ddb.NewUpdate().Add("Count", 1), ddb.IfExists())
+	assert.Equal(t, ddb.ErrConditionFailed, err)
+
+	// key attributes can't be updated.
+	_, err = c.Update(ctx, key, ddb.NewUpdate().Set("SK", "2"))
+	assert.Error(t, err)
+
+	tx := c.NewTransaction()
+	tx.Update(key, ddb.NewUpdate().Remove("Tags").Add("Count", 1))
+	tx.Put(counter{ID: "3"})
+	err = tx.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = counter{}
+	_, err = c.Get(ctx, key, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, counter{ID: "1", Count: 4}, got)
+}
+
+func TestGetBatch(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	keys := []ddb.GetKey{{PK: "pear", SK: "4"}, {PK: "apple", SK: "1"}, {PK: "apple", SK: "9"}}
+
+	var got []thing
+	res, err := c.GetBatch(ctx, keys, &got)
+	assert.Equal(t, &ddb.MissingKeysError{Keys: []ddb.GetKey{{PK: "apple", SK: "9"}}}, err)
+	assert.Equal(t, []ddb.GetKey{{PK: "apple", SK: "9"}}, res.Missing)
+	assert.Equal(t, []thing{fixtures[3], fixtures[0]}, got)
+
+	var byKey map[ddb.GetKey]*thing
+	_, err = c.GetBatch(ctx, keys[:2], &byKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[ddb.GetKey]*thing{
+		{PK: "pear", SK: "4"}:  &fixtures[3],
+		{PK: "apple", SK: "1"}: &fixtures[0],
+	}, byKey)
+}
+
+type scanThings struct {
+	Color  string
+	Result []thing `ddb:"result"`
+}
+
+func (s *scanThings) BuildScan() (*dynamodb.ScanInput, error) {
+	si := dynamodb.ScanInput{}
+	if s.Color != "" {
+		si.FilterExpression = aws.String("Color = :color")
+		si.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":color": &types.AttributeValueMemberS{Value: s.Color},
+		}
+	}
+	return &si, nil
+}
+
+func TestScan(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	s := &scanThings{Color: "green"}
+	_, err := c.Scan(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{fixtures[1], fixtures[3]}, s.Result)
+
+	// page through the table.
+	var got []thing
+	var page string
+	for {
+		s := &scanThings{}
+		res, err := c.Scan(ctx, s, ddb.Limit(3), ddb.Page(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, s.Result...)
+		if res.NextPage == "" {
+			break
+		}
+		page = res.NextPage
+	}
+	assert.Equal(t, fixtures, got)
+}
+
+func TestScanAll(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	for i := 0; i < 50; i++ {
+		err := c.Put(ctx, thing{Type: fmt.Sprintf("type%d", i), ID: "1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts []func(*ddb.QueryOpts)
+		want int
+	}{
+		{name: "sequential", opts: []func(*ddb.QueryOpts){ddb.Limit(7)}, want: 54},
+		{name: "parallel", opts: []func(*ddb.QueryOpts){ddb.Limit(7), ddb.TotalSegments(4)}, want: 54},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			seen := map[thing]bool{}
+			err := c.ScanAll(ctx, &scanThings{}, func(items []map[string]types.AttributeValue) error {
+				var things []thing
+				err := attributevalue.UnmarshalListOfMaps(items, &things)
+				if err != nil {
+					return err
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, th := range things {
+					assert.False(t, seen[th], "item seen more than once")
+					seen[th] = true
+				}
+				return nil
+			}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Len(t, seen, tt.want)
+		})
+	}
+
+	// segments partition the table.
+	total := 0
+	for i := int32(0); i < 3; i++ {
+		err := c.ScanAll(ctx, &scanThings{}, func(items []map[string]types.AttributeValue) error {
+			total += len(items)
+			return nil
+		}, ddb.Segment(i, 3))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Equal(t, 54, total)
+
+	// returning an error stops the scan.
+	stop := errors.New("stop")
+	calls := 0
+	err := c.ScanAll(ctx, &scanThings{}, func(items []map[string]types.AttributeValue) error {
+		calls++
+		return stop
+	}, ddb.Limit(1))
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIterate(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	q := &listThings{Type: "apple"}
+	it := c.Iterate(ctx, q, ddb.Limit(2))
+	var got []thing
+	pages := 0
+	for it.Next(ctx) {
+		pages++
+		got = append(got, q.Result...)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, fixtures[0:3], got)
+}
+
+func TestTableSchema(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx, ddb.WithTableSchema(ddb.TableSchema{
+		PartitionKey: "pk",
+		SortKey:      "sk",
+		Indexes: []ddb.IndexSchema{
+			{Name: "gsi1", PartitionKey: "gsi1pk", SortKey: "gsi1sk"},
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.PutBatch(ctx, fixtures[0], fixtures[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Put(ctx, fixtures[2], ddb.IfNotExists())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Put(ctx, fixtures[2], ddb.IfNotExists())
+	assert.Equal(t, ddb.ErrConditionFailed, err)
+
+	table := c.API().(*Table)
+	out, err := table.GetItem(ctx, &dynamodb.GetItemInput{Key: map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "apple"},
+		"sk": &types.AttributeValueMemberS{Value: "1"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "red"}, out.Item["gsi1pk"])
+	assert.Nil(t, out.Item["PK"])
+
+	var got thing
+	_, err = c.Get(ctx, ddb.GetKey{PK: "apple", SK: "2"}, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtures[1], got)
+
+	var batch []thing
+	_, err = c.GetBatch(ctx, []ddb.GetKey{{PK: "apple", SK: "1"}, {PK: "apple", SK: "3"}}, &batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{fixtures[0], fixtures[2]}, batch)
+
+	_, err = c.Update(ctx, ddb.GetKey{PK: "apple", SK: "1"}, ddb.NewUpdate().Set("Color", "yellow"), ddb.IfExists())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := c.NewTransaction()
+	tx.Delete(fixtures[1])
+	err = tx.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Delete(ctx, fixtures[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, table.Len())
+}
+
+type event struct {
+	Stream    string
+	Timestamp int64
+}
+
+func (e event) DDBKeys() (ddb.Keys, error) {
+	return ddb.Keys{PK: e.Stream, SK: strconv.FormatInt(e.Timestamp, 10)}, nil
+}
+
+type listEvents struct {
+	Stream string
+	Result []event `ddb:"result"`
+}
+
+func (l *listEvents) BuildQuery() (*dynamodb.QueryInput, error) {
+	return &dynamodb.QueryInput{
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: l.Stream},
+		},
+	}, nil
+}
+
+func TestNumericSortKey(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx, ddb.WithTableSchema(ddb.TableSchema{
+		PartitionKey: "PK",
+		SortKey:      "SK",
+		SortKeyType:  types.ScalarAttributeTypeN,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// numeric sort keys are ordered by value, rather than lexicographically.
+	events := []event{{Stream: "s", Timestamp: 9}, {Stream: "s", Timestamp: 10}, {Stream: "s", Timestamp: 100}}
+	err = c.PutBatch(ctx, events[2], events[0], events[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := c.API().GetItem(ctx, &dynamodb.GetItemInput{Key: map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "s"},
+		"SK": &types.AttributeValueMemberN{Value: "10"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "10"}, out.Item["SK"])
+
+	// page tokens preserve the numeric key type.
+	q := &listEvents{Stream: "s"}
+	err = c.All(ctx, q, ddb.Limit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, events, q.Result)
+
+	var e event
+	_, err = c.Get(ctx, ddb.GetKey{PK: "s", SK: "100"}, &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, events[2], e)
+
+	err = c.DeleteBatch(ctx, events[0], events[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Delete(ctx, events[2], ddb.IfExists())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, c.API().(*Table).Len())
+}
+
+type profile struct {
+	ID    string   `ddb:"pk,template=PROFILE#{ID}" dynamodbav:"-"`
+	_     struct{} `ddb:"sk,template=PROFILE"`
+	Email string
+}
+
+func TestTaggedItems(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := profile{ID: "1", Email: "alice@example.com"}
+	err = c.Put(ctx, ddb.Tagged(&a), ddb.IfNotExists())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got profile
+	_, err = c.Get(ctx, ddb.GetKey{PK: "PROFILE#1", SK: "PROFILE"}, ddb.Tagged(&got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, a, got)
+
+	err = c.Delete(ctx, ddb.Tagged(&got), ddb.IfExists())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, c.API().(*Table).Len())
+}
+
+type queryThings struct {
+	Query  *ddb.QueryExpression
+	Result []thing `ddb:"result"`
+}
+
+func (q *queryThings) BuildQuery() (*dynamodb.QueryInput, error) {
+	return q.Query.BuildQuery()
+}
+
+func TestQueryExpression(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	things := []thing{
+		{Type: "fruit", ID: "1", Color: "red"},
+		{Type: "fruit", ID: "2", Color: "green"},
+		{Type: "fruit", ID: "3", Color: "red"},
+		{Type: "veg", ID: "4", Color: "red"},
+	}
+	for _, th := range things {
+		if err := c.Put(ctx, th); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		give *ddb.QueryExpression
+		want []thing
+	}{
+		{
+			name: "partition key",
+			give: ddb.Q().PK("fruit"),
+			want: things[:3],
+		},
+		{
+			name: "sort key",
+			give: ddb.Q().PK("fruit").SKGreaterThanOrEqual("2"),
+			want: things[1:3],
+		},
+		{
+			name: "filter",
+			give: ddb.Q().PK("fruit").Filter("Color = ? AND NOT ID IN (?)", "red", "3"),
+			want: things[:1],
+		},
+		{
+			name: "index",
+			give: ddb.Q().Index("GSI1").PK("red").SKBetween("3", "4"),
+			want: things[2:],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &queryThings{Query: tt.give}
+			_, err := c.Query(ctx, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, q.Result)
+		})
+	}
+}
+
+func TestQueryOptions(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	things := []thing{
+		{Type: "fruit", ID: "1", Color: "red"},
+		{Type: "fruit", ID: "2", Color: "green"},
+		{Type: "fruit", ID: "3", Color: "red"},
+	}
+	err = c.PutBatch(ctx, things[0], things[1], things[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := &listThings{Type: "fruit"}
+	_, err = c.Query(ctx, q, ddb.Reverse(), ddb.Limit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{things[2], things[1]}, q.Result)
+
+	q = &listThings{Type: "fruit"}
+	err = c.All(ctx, q, ddb.Reverse(), ddb.Project("ID"), ddb.Limit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{ID: "3"}, {ID: "2"}, {ID: "1"}}, q.Result)
+
+	var n int
+	res, err := c.Query(ctx, &listThings{Type: "fruit", Color: "red"}, ddb.Count(&n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, res.Count)
+
+	err = c.All(ctx, &listThings{Type: "fruit"}, ddb.Count(&n), ddb.Limit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, n)
+}
+
+type order struct {
+	ID     string   `ddb:"pk,template=ORDER#{ID}"`
+	_      struct{} `ddb:"sk,template=ORDER"`
+	Status string
+}
+
+type orderLine struct {
+	OrderID string `ddb:"pk,template=ORDER#{OrderID}"`
+	Line    int    `ddb:"sk,template=LINE#{Line}"`
+}
+
+type getOrder struct {
+	ID     string
+	Result ddb.Entities `ddb:"result"`
+}
+
+func (g *getOrder) BuildQuery() (*dynamodb.QueryInput, error) {
+	return ddb.Q().PK("ORDER#" + g.ID).BuildQuery()
+}
+
+func TestEntities(t *testing.T) {
+	ddb.Register[order]("order")
+	ddb.Register[orderLine]("orderLine")
+
+	ctx := context.Background()
+	c, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.PutBatch(ctx,
+		ddb.Tagged(order{ID: "1", Status: "PAID"}),
+		ddb.Tagged(orderLine{OrderID: "1", Line: 1}),
+		ddb.Tagged(orderLine{OrderID: "1", Line: 2}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := &getOrder{ID: "1"}
+	err = c.All(ctx, q, ddb.Limit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ddb.Entities{orderLine{OrderID: "1", Line: 1}, orderLine{OrderID: "1", Line: 2}, order{ID: "1", Status: "PAID"}}, q.Result)
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	things := ddb.NewRepository[thing](c)
+
+	err = things.PutBatch(ctx, thing{Type: "fruit", ID: "1"}, thing{Type: "fruit", ID: "2"}, thing{Type: "fruit", ID: "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := things.Get(ctx, ddb.GetKey{PK: "fruit", SK: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, thing{Type: "fruit", ID: "2"}, got)
+
+	_, err = things.Get(ctx, ddb.GetKey{PK: "fruit", SK: "4"})
+	assert.ErrorIs(t, err, ddb.ErrNoItems)
+
+	page, next, err := things.Query(ctx, ddb.Q().PK("fruit"), ddb.Limit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{Type: "fruit", ID: "1"}, {Type: "fruit", ID: "2"}}, page)
+
+	rest, err := things.All(ctx, &listThings{Type: "fruit"}, ddb.Page(next))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{Type: "fruit", ID: "3"}}, rest)
+
+	batch, err := things.GetBatch(ctx, []ddb.GetKey{{PK: "fruit", SK: "3"}, {PK: "fruit", SK: "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{Type: "fruit", ID: "3"}, {Type: "fruit", ID: "1"}}, batch)
+
+	err = things.DeleteBatch(ctx, batch...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = things.Delete(ctx, got, ddb.IfExists())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, c.API().(*Table).Len())
+
+	// tagged items without a DDBKeys method can be read with GetAs.
+	err = c.Put(ctx, ddb.Tagged(&profile{ID: "1", Email: "a@example.com"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := ddb.GetAs[*profile](ctx, c, ddb.GetKey{PK: "PROFILE#1", SK: "PROFILE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &profile{ID: "1", Email: "a@example.com"}, p)
+}
+
+func TestGetByIndex(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.PutBatch(ctx,
+		thing{Type: "fruit", ID: "1", Color: "red"},
+		thing{Type: "fruit", ID: "2", Color: "green"},
+		thing{Type: "veg", ID: "3", Color: "green"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got thing
+	err = c.GetByIndex(ctx, "GSI1", "red", "", &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, thing{Type: "fruit", ID: "1", Color: "red"}, got)
+
+	err = c.GetByIndex(ctx, "GSI1", "green", "3", &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, thing{Type: "veg", ID: "3", Color: "green"}, got)
+
+	err = c.GetByIndex(ctx, "GSI1", "green", "", &got)
+	assert.ErrorIs(t, err, ddb.ErrMultipleItems)
+
+	err = c.GetByIndex(ctx, "GSI1", "blue", "", &got)
+	assert.ErrorIs(t, err, ddb.ErrNoItems)
+
+	err = c.GetByIndex(ctx, "GSI9", "red", "", &got)
+	assert.Error(t, err)
+}
+
+func TestQueryOne(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.PutBatch(ctx,
+		thing{Type: "fruit", ID: "1", Color: "red"},
+		thing{Type: "fruit", ID: "2", Color: "green"},
+		thing{Type: "fruit", ID: "3", Color: "yellow"},
+		thing{Type: "fruit", ID: "4", Color: "green"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with a limit of one item per page, the matching item is only found on a later page.
+	var got thing
+	err = c.QueryOne(ctx, ddb.Q().PK("fruit").Filter("Color = ?", "yellow"), &got, ddb.Limit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, thing{Type: "fruit", ID: "3", Color: "yellow"}, got)
+
+	err = c.QueryOne(ctx, ddb.Q().PK("fruit").Filter("Color = ?", "green"), &got, ddb.Limit(1))
+	assert.ErrorIs(t, err, ddb.ErrMultipleItems)
+	var multi *ddb.MultipleItemsError
+	if assert.ErrorAs(t, err, &multi) {
+		assert.Equal(t, 2, multi.Count)
+	}
+
+	err = c.QueryOne(ctx, ddb.Q().PK("fruit").Filter("Color = ?", "blue"), &got, ddb.Limit(1))
+	assert.ErrorIs(t, err, ddb.ErrNoItems)
+}
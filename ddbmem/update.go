@@ -0,0 +1,474 @@
+package ddbmem
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// This file contains a parser and evaluator for DynamoDB update expressions.
+//
+// see: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Expressions.UpdateExpressions.html
+
+type setAction struct {
+	path  path
+	value operand
+}
+
+type setValueAction struct {
+	path  path
+	value types.AttributeValue
+}
+
+// updateExpression is a parsed update expression.
+type updateExpression struct {
+	set    []setAction
+	remove []path
+	add    []setValueAction
+	delete []setValueAction
+}
+
+// parseUpdate parses an update expression.
+func parseUpdate(expr string, names map[string]string, values map[string]types.AttributeValue) (*updateExpression, error) {
+	p, err := newParser(expr, names, values)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &updateExpression{}
+	seen := map[string]bool{}
+	for p.peek().kind != tokenEOF {
+		t := p.next()
+		clause := strings.ToUpper(t.text)
+		if t.kind != tokenIdent || (clause != "SET" && clause != "REMOVE" && clause != "ADD" && clause != "DELETE") {
+			return nil, p.errorf("expected SET, REMOVE, ADD or DELETE but found %q", t.text)
+		}
+		if seen[clause] {
+			return nil, p.errorf("the %s clause may only be used once", clause)
+		}
+		seen[clause] = true
+
+		for {
+			if err := p.parseAction(u, clause); err != nil {
+				return nil, err
+			}
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if len(seen) == 0 {
+		return nil, p.errorf("update expression must not be empty")
+	}
+	return u, nil
+}
+
+// parseAction parses a single action within a clause.
+func (p *parser) parseAction(u *updateExpression, clause string) error {
+	pa, err := p.parsePath()
+	if err != nil {
+		return err
+	}
+
+	switch clause {
+	case "SET":
+		if err := p.expect(tokenOperator, "="); err != nil {
+			return err
+		}
+		v, err := p.parseSetValue()
+		if err != nil {
+			return err
+		}
+		u.set = append(u.set, setAction{path: pa, value: v})
+	case "REMOVE":
+		u.remove = append(u.remove, pa)
+	default:
+		t := p.next()
+		if t.kind != tokenValue {
+			return p.errorf("%s expects a value placeholder but found %q", clause, t.text)
+		}
+		v, ok := p.values[t.text]
+		if !ok {
+			return p.errorf("value placeholder %s is not defined in ExpressionAttributeValues", t.text)
+		}
+		action := setValueAction{path: pa, value: v}
+		if clause == "ADD" {
+			u.add = append(u.add, action)
+		} else {
+			u.delete = append(u.delete, action)
+		}
+	}
+	return nil
+}
+
+// parseSetValue parses the right hand side of a SET action,
+// which may add or subtract two operands.
+func (p *parser) parseSetValue() (operand, error) {
+	left, err := p.parseSetOperand()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokenOperator && (t.text == "+" || t.text == "-") {
+		p.next()
+		right, err := p.parseSetOperand()
+		if err != nil {
+			return nil, err
+		}
+		return arithmeticOperand{op: t.text, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseSetOperand() (operand, error) {
+	t := p.peek()
+	if t.kind == tokenIdent && p.tokens[p.pos+1].kind == tokenLParen {
+		fn := strings.ToLower(t.text)
+		switch fn {
+		case "if_not_exists":
+			p.next()
+			p.next()
+			pa, err := p.parsePath()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokenComma, ","); err != nil {
+				return nil, err
+			}
+			fallback, err := p.parseSetOperand()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokenRParen, ")"); err != nil {
+				return nil, err
+			}
+			return ifNotExistsOperand{path: pa, fallback: fallback}, nil
+		case "list_append":
+			p.next()
+			p.next()
+			a, err := p.parseSetOperand()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokenComma, ","); err != nil {
+				return nil, err
+			}
+			b, err := p.parseSetOperand()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokenRParen, ")"); err != nil {
+				return nil, err
+			}
+			return listAppendOperand{a: a, b: b}, nil
+		}
+	}
+	if t.kind == tokenValue {
+		return p.parseOperand()
+	}
+	return p.parsePath()
+}
+
+type arithmeticOperand struct {
+	op          string
+	left, right operand
+}
+
+func (a arithmeticOperand) resolve(item map[string]types.AttributeValue) (types.AttributeValue, error) {
+	l, err := a.left.resolve(item)
+	if err != nil {
+		return nil, err
+	}
+	r, err := a.right.resolve(item)
+	if err != nil {
+		return nil, err
+	}
+	ln, lok := l.(*types.AttributeValueMemberN)
+	rn, rok := r.(*types.AttributeValueMemberN)
+	if !lok || !rok {
+		return nil, fmt.Errorf("an operand in the update expression has an incorrect data type")
+	}
+	lf, ok := parseNumber(ln.Value)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", ln.Value)
+	}
+	rf, ok := parseNumber(rn.Value)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", rn.Value)
+	}
+	if a.op == "+" {
+		lf.Add(lf, rf)
+	} else {
+		lf.Sub(lf, rf)
+	}
+	return &types.AttributeValueMemberN{Value: lf.Text('f', -1)}, nil
+}
+
+type ifNotExistsOperand struct {
+	path     path
+	fallback operand
+}
+
+func (o ifNotExistsOperand) resolve(item map[string]types.AttributeValue) (types.AttributeValue, error) {
+	if v := o.path.get(item); v != nil {
+		return v, nil
+	}
+	return o.fallback.resolve(item)
+}
+
+type listAppendOperand struct {
+	a, b operand
+}
+
+func (o listAppendOperand) resolve(item map[string]types.AttributeValue) (types.AttributeValue, error) {
+	a, err := o.a.resolve(item)
+	if err != nil {
+		return nil, err
+	}
+	b, err := o.b.resolve(item)
+	if err != nil {
+		return nil, err
+	}
+	al, aok := a.(*types.AttributeValueMemberL)
+	bl, bok := b.(*types.AttributeValueMemberL)
+	if !aok || !bok {
+		return nil, fmt.Errorf("list_append expects two lists")
+	}
+	out := append(append([]types.AttributeValue{}, al.Value...), bl.Value...)
+	return &types.AttributeValueMemberL{Value: out}, nil
+}
+
+// paths returns all of the document paths modified by the update.
+func (u *updateExpression) paths() []path {
+	var out []path
+	for _, a := range u.set {
+		out = append(out, a.path)
+	}
+	out = append(out, u.remove...)
+	for _, a := range u.add {
+		out = append(out, a.path)
+	}
+	for _, a := range u.delete {
+		out = append(out, a.path)
+	}
+	return out
+}
+
+// apply returns a copy of the item with the update applied.
+// Values are resolved against the item as it was before the update.
+func (u *updateExpression) apply(item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	setValues := make([]types.AttributeValue, len(u.set))
+	for i, a := range u.set {
+		v, err := a.value.resolve(item)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return nil, fmt.Errorf("the provided expression refers to an attribute that does not exist in the item: %s", a.path)
+		}
+		setValues[i] = v
+	}
+
+	out := copyItem(item)
+	for i, a := range u.set {
+		if err := a.path.set(out, copyValue(setValues[i])); err != nil {
+			return nil, err
+		}
+	}
+	for _, pa := range u.remove {
+		pa.remove(out)
+	}
+	for _, a := range u.add {
+		v, err := addValues(a.path.get(out), a.value)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.path.set(out, v); err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range u.delete {
+		existing := a.path.get(out)
+		if existing == nil {
+			continue
+		}
+		v, err := deleteValues(existing, a.value)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			a.path.remove(out)
+			continue
+		}
+		if err := a.path.set(out, v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// addValues implements the ADD action for numbers and sets.
+func addValues(existing, v types.AttributeValue) (types.AttributeValue, error) {
+	if existing == nil {
+		return copyValue(v), nil
+	}
+	switch e := existing.(type) {
+	case *types.AttributeValueMemberN:
+		return arithmeticOperand{op: "+", left: valueOperand{e}, right: valueOperand{v}}.resolve(nil)
+	case *types.AttributeValueMemberSS:
+		add, ok := v.(*types.AttributeValueMemberSS)
+		if !ok {
+			return nil, fmt.Errorf("an operand in the update expression has an incorrect data type")
+		}
+		out := append([]string{}, e.Value...)
+		for _, s := range add.Value {
+			if !containsString(out, s) {
+				out = append(out, s)
+			}
+		}
+		return &types.AttributeValueMemberSS{Value: out}, nil
+	case *types.AttributeValueMemberNS:
+		add, ok := v.(*types.AttributeValueMemberNS)
+		if !ok {
+			return nil, fmt.Errorf("an operand in the update expression has an incorrect data type")
+		}
+		out := append([]string{}, e.Value...)
+		for _, n := range add.Value {
+			var found bool
+			for _, other := range out {
+				if equal(&types.AttributeValueMemberN{Value: n}, &types.AttributeValueMemberN{Value: other}) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				out = append(out, n)
+			}
+		}
+		return &types.AttributeValueMemberNS{Value: out}, nil
+	case *types.AttributeValueMemberBS:
+		add, ok := v.(*types.AttributeValueMemberBS)
+		if !ok {
+			return nil, fmt.Errorf("an operand in the update expression has an incorrect data type")
+		}
+		out := copyValue(e).(*types.AttributeValueMemberBS)
+		for _, b := range add.Value {
+			var found bool
+			for _, other := range out.Value {
+				if string(b) == string(other) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				out.Value = append(out.Value, b)
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("ADD can only be used on numbers and sets, got %s", typeName(existing))
+}
+
+// deleteValues implements the DELETE action for sets.
+// It returns nil if the resulting set is empty.
+func deleteValues(existing, v types.AttributeValue) (types.AttributeValue, error) {
+	if typeName(existing) != typeName(v) {
+		return nil, fmt.Errorf("an operand in the update expression has an incorrect data type")
+	}
+	var out types.AttributeValue
+	switch e := existing.(type) {
+	case *types.AttributeValueMemberSS:
+		var kept []string
+		for _, s := range e.Value {
+			if !containsString(v.(*types.AttributeValueMemberSS).Value, s) {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) > 0 {
+			out = &types.AttributeValueMemberSS{Value: kept}
+		}
+	case *types.AttributeValueMemberNS:
+		var kept []string
+		for _, n := range e.Value {
+			var found bool
+			for _, other := range v.(*types.AttributeValueMemberNS).Value {
+				if equal(&types.AttributeValueMemberN{Value: n}, &types.AttributeValueMemberN{Value: other}) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				kept = append(kept, n)
+			}
+		}
+		if len(kept) > 0 {
+			out = &types.AttributeValueMemberNS{Value: kept}
+		}
+	case *types.AttributeValueMemberBS:
+		var kept [][]byte
+		for _, b := range e.Value {
+			var found bool
+			for _, other := range v.(*types.AttributeValueMemberBS).Value {
+				if string(b) == string(other) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				kept = append(kept, b)
+			}
+		}
+		if len(kept) > 0 {
+			out = &types.AttributeValueMemberBS{Value: kept}
+		}
+	default:
+		return nil, fmt.Errorf("DELETE can only be used on sets, got %s", typeName(existing))
+	}
+	return out, nil
+}
+
+// set sets the value at the path. The parent of the path must exist.
+func (pa path) set(item map[string]types.AttributeValue, v types.AttributeValue) error {
+	if len(pa) == 1 {
+		item[pa[0].name] = v
+		return nil
+	}
+	parent := pa[:len(pa)-1].get(item)
+	last := pa[len(pa)-1]
+	switch p := parent.(type) {
+	case *types.AttributeValueMemberM:
+		if !last.isIndex {
+			p.Value[last.name] = v
+			return nil
+		}
+	case *types.AttributeValueMemberL:
+		if last.isIndex {
+			if last.index < len(p.Value) {
+				p.Value[last.index] = v
+			} else {
+				p.Value = append(p.Value, v)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("the document path provided in the update expression is invalid for update: %s", pa)
+}
+
+// remove removes the value at the path, if it exists.
+func (pa path) remove(item map[string]types.AttributeValue) {
+	if len(pa) == 1 {
+		delete(item, pa[0].name)
+		return
+	}
+	parent := pa[:len(pa)-1].get(item)
+	last := pa[len(pa)-1]
+	switch p := parent.(type) {
+	case *types.AttributeValueMemberM:
+		delete(p.Value, last.name)
+	case *types.AttributeValueMemberL:
+		if last.isIndex && last.index < len(p.Value) {
+			p.Value = append(p.Value[:last.index], p.Value[last.index+1:]...)
+		}
+	}
+}
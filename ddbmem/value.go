@@ -0,0 +1,218 @@
+package ddbmem
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// typeName returns the DynamoDB data type descriptor of an attribute value,
+// such as "S" or "N".
+func typeName(v types.AttributeValue) string {
+	switch v.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	}
+	return ""
+}
+
+// parseNumber parses a DynamoDB number.
+func parseNumber(s string) (*big.Float, bool) {
+	f, ok := new(big.Float).SetPrec(200).SetString(s)
+	return f, ok
+}
+
+// compare compares two scalar attribute values of the same type.
+// It returns false if the values can't be compared.
+func compare(a, b types.AttributeValue) (int, bool) {
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS:
+		b, ok := b.(*types.AttributeValueMemberS)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a.Value < b.Value:
+			return -1, true
+		case a.Value > b.Value:
+			return 1, true
+		}
+		return 0, true
+	case *types.AttributeValueMemberN:
+		b, ok := b.(*types.AttributeValueMemberN)
+		if !ok {
+			return 0, false
+		}
+		fa, ok := parseNumber(a.Value)
+		if !ok {
+			return 0, false
+		}
+		fb, ok := parseNumber(b.Value)
+		if !ok {
+			return 0, false
+		}
+		return fa.Cmp(fb), true
+	case *types.AttributeValueMemberB:
+		b, ok := b.(*types.AttributeValueMemberB)
+		if !ok {
+			return 0, false
+		}
+		return bytes.Compare(a.Value, b.Value), true
+	}
+	return 0, false
+}
+
+// equal reports whether two attribute values are equal.
+func equal(a, b types.AttributeValue) bool {
+	if typeName(a) != typeName(b) {
+		return false
+	}
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS, *types.AttributeValueMemberN, *types.AttributeValueMemberB:
+		cmp, ok := compare(a, b)
+		return ok && cmp == 0
+	case *types.AttributeValueMemberBOOL:
+		return a.Value == b.(*types.AttributeValueMemberBOOL).Value
+	case *types.AttributeValueMemberNULL:
+		return true
+	case *types.AttributeValueMemberL:
+		bl := b.(*types.AttributeValueMemberL).Value
+		if len(a.Value) != len(bl) {
+			return false
+		}
+		for i := range a.Value {
+			if !equal(a.Value[i], bl[i]) {
+				return false
+			}
+		}
+		return true
+	case *types.AttributeValueMemberM:
+		bm := b.(*types.AttributeValueMemberM).Value
+		if len(a.Value) != len(bm) {
+			return false
+		}
+		for k, v := range a.Value {
+			other, ok := bm[k]
+			if !ok || !equal(v, other) {
+				return false
+			}
+		}
+		return true
+	case *types.AttributeValueMemberSS:
+		return equalSets(a.Value, b.(*types.AttributeValueMemberSS).Value)
+	case *types.AttributeValueMemberNS:
+		bs := b.(*types.AttributeValueMemberNS).Value
+		if len(a.Value) != len(bs) {
+			return false
+		}
+		for _, n := range a.Value {
+			var found bool
+			for _, other := range bs {
+				if equal(&types.AttributeValueMemberN{Value: n}, &types.AttributeValueMemberN{Value: other}) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case *types.AttributeValueMemberBS:
+		bs := b.(*types.AttributeValueMemberBS).Value
+		as := make([]string, len(a.Value))
+		for i := range a.Value {
+			as[i] = string(a.Value[i])
+		}
+		other := make([]string, len(bs))
+		for i := range bs {
+			other[i] = string(bs[i])
+		}
+		return equalSets(as, other)
+	}
+	return false
+}
+
+func equalSets(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	as := append([]string{}, a...)
+	bs := append([]string{}, b...)
+	sort.Strings(as)
+	sort.Strings(bs)
+	for i := range as {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// copyValue returns a deep copy of an attribute value, so that
+// items held in memory can't be modified by callers.
+func copyValue(v types.AttributeValue) types.AttributeValue {
+	switch v := v.(type) {
+	case *types.AttributeValueMemberS:
+		return &types.AttributeValueMemberS{Value: v.Value}
+	case *types.AttributeValueMemberN:
+		return &types.AttributeValueMemberN{Value: v.Value}
+	case *types.AttributeValueMemberB:
+		return &types.AttributeValueMemberB{Value: append([]byte{}, v.Value...)}
+	case *types.AttributeValueMemberBOOL:
+		return &types.AttributeValueMemberBOOL{Value: v.Value}
+	case *types.AttributeValueMemberNULL:
+		return &types.AttributeValueMemberNULL{Value: v.Value}
+	case *types.AttributeValueMemberL:
+		l := make([]types.AttributeValue, len(v.Value))
+		for i := range v.Value {
+			l[i] = copyValue(v.Value[i])
+		}
+		return &types.AttributeValueMemberL{Value: l}
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: copyItem(v.Value)}
+	case *types.AttributeValueMemberSS:
+		return &types.AttributeValueMemberSS{Value: append([]string{}, v.Value...)}
+	case *types.AttributeValueMemberNS:
+		return &types.AttributeValueMemberNS{Value: append([]string{}, v.Value...)}
+	case *types.AttributeValueMemberBS:
+		bs := make([][]byte, len(v.Value))
+		for i := range v.Value {
+			bs[i] = append([]byte{}, v.Value[i]...)
+		}
+		return &types.AttributeValueMemberBS{Value: bs}
+	}
+	return v
+}
+
+// copyItem returns a deep copy of an item.
+func copyItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	out := make(map[string]types.AttributeValue, len(item))
+	for k, v := range item {
+		out[k] = copyValue(v)
+	}
+	return out
+}
@@ -2,10 +2,14 @@ package ddbmock
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"strconv"
 	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/common-fate/ddb"
 )
 
@@ -17,10 +21,27 @@ type Client struct {
 	mu         *sync.Mutex
 	results    map[reflect.Type]mockResult
 	getResults map[ddb.GetKey]mockGetResult
+	// indexResults are the mocked results for GetByIndex().
+	indexResults map[indexKey]mockGetResult
+	// queryOneResults are the mocked results for QueryOne(), keyed by QueryBuilder type.
+	queryOneResults map[reflect.Type]mockGetResult
+	// scanResults are the mocked results for Scan(), keyed by ScanBuilder type.
+	scanResults map[reflect.Type]mockScanResult
+	// counts are the mocked results of queries which use ddb.Count(), keyed by QueryBuilder type.
+	counts map[reflect.Type]int
+	// conditionFailures are the keys of items which fail conditional writes.
+	conditionFailures map[ddb.GetKey]bool
+	updateResults     map[ddb.GetKey]interface{}
+	// transactions are the transactions created by NewTransaction().
+	transactions []*MockTransaction
 	// DeleteErr causes Delete() to return an error if it is set
 	DeleteErr error
 	// PutErr causes Put() to return an error if it is set
 	PutErr error
+	// UpdateErr causes Update() to return an error if it is set
+	UpdateErr error
+	// GetBatchErr causes GetBatch() to return an error if it is set
+	GetBatchErr error
 	// PutBatchErr causes PutBatch() to return an error if it is set
 	PutBatchErr error
 	// DeleteBatchErr causes DeleteBatch() to return an error if it is set
@@ -30,6 +51,9 @@ type Client struct {
 	// TransactionExecuteError causes Transaction objects created from this client
 	// to fail with this error if set.
 	TransactionExecuteErr error
+	// TransactionValidateErr causes Transaction objects created from this client
+	// to fail validation with this error if set.
+	TransactionValidateErr error
 }
 
 // mockGetResult is the mocked result when Get() is called.
@@ -39,11 +63,21 @@ type mockGetResult struct {
 	err   error
 }
 
+// mockScanResult is the mocked result when Scan() or ScanAll() is called.
+type mockScanResult struct {
+	value interface{}
+	items []map[string]types.AttributeValue
+	err   error
+}
+
 // mockResult is the mocked result when Query() is called.
 type mockResult struct {
 	res   *ddb.QueryResult
 	value interface{}
 	err   error
+	// pages are the results of each page, if the query
+	// has been mocked with MockQueryPages.
+	pages []ddb.QueryBuilder
 }
 
 // New creates a new mock client which satisfies the ddb.Storage interface.
@@ -53,7 +87,83 @@ func New(t TestReporter) *Client {
 		mu:         &sync.Mutex{},
 		results:    make(map[reflect.Type]mockResult),
 		getResults: make(map[ddb.GetKey]mockGetResult),
+
+		indexResults:    make(map[indexKey]mockGetResult),
+		queryOneResults: make(map[reflect.Type]mockGetResult),
+
+		scanResults: make(map[reflect.Type]mockScanResult),
+		counts:      make(map[reflect.Type]int),
+
+		conditionFailures: make(map[ddb.GetKey]bool),
+		updateResults:     make(map[ddb.GetKey]interface{}),
+	}
+}
+
+// MockUpdate mocks the item returned by a DynamoDB Update operation.
+// When Update() is called for the key with the ddb.ReturnNew() or ddb.ReturnOld()
+// options, the item passed to the option is set to the provided result.
+//
+// For example:
+//
+//	db := ddbmock.New(t)
+//	db.MockUpdate(ddb.GetKey{PK: "1", SK: "1"}, &Apple{Count: 2})
+//
+//	var got Apple
+//	db.Update(ctx, ddb.GetKey{PK: "1", SK: "1"}, ddb.NewUpdate().Add("Count", 1), ddb.ReturnNew(&got))
+//	// got now contains Apple{Count: 2} as defined by MockUpdate.
+func (m *Client) MockUpdate(key ddb.GetKey, result interface{}) {
+	// acquire a mutex lock in case the client is being used across multiple goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.updateResults[key] = result
+}
+
+// MockConditionFailed causes conditional writes to the item with the provided
+// key to fail with ddb.ErrConditionFailed. Writes made without a condition
+// still succeed.
+//
+// For example:
+//
+//	db := ddbmock.New(t)
+//	db.MockConditionFailed(ddb.GetKey{PK: "1", SK: "1"})
+//
+//	err := db.Put(ctx, Apple{ID: "1"}, ddb.IfNotExists())
+//	// err is equal to ddb.ErrConditionFailed.
+func (m *Client) MockConditionFailed(key ddb.GetKey) {
+	// acquire a mutex lock in case the client is being used across multiple goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.conditionFailures[key] = true
+}
+
+// checkCondition returns ddb.ErrConditionFailed if the write is conditional
+// and the item has been registered with MockConditionFailed.
+func (m *Client) checkCondition(item ddb.Keyer, opts []func(*ddb.WriteOpts)) error {
+	keys, err := item.DDBKeys()
+	if err != nil {
+		return err
+	}
+	return m.checkKeyCondition(ddb.GetKey{PK: keys.PK, SK: keys.SK}, opts)
+}
+
+func (m *Client) checkKeyCondition(key ddb.GetKey, opts []func(*ddb.WriteOpts)) error {
+	wo := ddb.WriteOpts{}
+	for _, o := range opts {
+		o(&wo)
+	}
+	if !wo.HasCondition() {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.conditionFailures[key] {
+		return ddb.ErrConditionFailed
 	}
+	return nil
 }
 
 // MockGet mocks a DynamoDB Get operation.
@@ -78,6 +188,78 @@ func (m *Client) MockGet(key ddb.GetKey, result interface{}) {
 	}
 }
 
+// indexKey is the key of an item looked up with GetByIndex.
+type indexKey struct {
+	index string
+	pk    string
+	sk    string
+}
+
+// MockGetByIndex mocks a DynamoDB GetByIndex operation.
+// The provided result is used as the item.
+//
+// For example:
+//
+//	db := ddbmock.New(t)
+//	db.MockGetByIndex("GSI1", "EMAIL#alice@example.com", "", User{ID: "1"})
+//
+//	var got User
+//	db.GetByIndex(ctx, "GSI1", "EMAIL#alice@example.com", "", &got)
+//	// got now contains User{ID: "1"} as defined by MockGetByIndex.
+func (m *Client) MockGetByIndex(index, pk, sk string, result interface{}) {
+	// acquire a mutex lock in case the client is being used across multiple goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.indexResults[indexKey{index: index, pk: pk, sk: sk}] = mockGetResult{
+		value: result,
+	}
+}
+
+// MockGetByIndexWithErr mocks a DynamoDB GetByIndex operation which returns an error,
+// such as ddb.ErrNoItems or ddb.ErrMultipleItems.
+func (m *Client) MockGetByIndexWithErr(index, pk, sk string, err error) {
+	// acquire a mutex lock in case the client is being used across multiple goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.indexResults[indexKey{index: index, pk: pk, sk: sk}] = mockGetResult{
+		err: err,
+	}
+}
+
+// MockQueryOne mocks the item returned by QueryOne for the type of the provided QueryBuilder.
+//
+// For example:
+//
+//	db := ddbmock.New(t)
+//	db.MockQueryOne(&getUserByEmail{}, User{ID: "1"})
+//
+//	var got User
+//	db.QueryOne(ctx, &getUserByEmail{Email: "alice@example.com"}, &got)
+//	// got now contains User{ID: "1"} as defined by MockQueryOne.
+func (m *Client) MockQueryOne(qb ddb.QueryBuilder, result interface{}) {
+	// acquire a mutex lock in case the client is being used across multiple goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queryOneResults[reflect.TypeOf(qb)] = mockGetResult{
+		value: result,
+	}
+}
+
+// MockQueryOneWithErr mocks QueryOne returning an error, such as
+// ddb.ErrNoItems or &ddb.MultipleItemsError{Count: 2}.
+func (m *Client) MockQueryOneWithErr(qb ddb.QueryBuilder, err error) {
+	// acquire a mutex lock in case the client is being used across multiple goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queryOneResults[reflect.TypeOf(qb)] = mockGetResult{
+		err: err,
+	}
+}
+
 // MockQuery mocks a DynamoDB query.
 // The contents of the provided query will be used as the results.
 //
@@ -102,6 +284,57 @@ func (m *Client) MockQuery(qb ddb.QueryBuilder) {
 	}
 }
 
+// MockQueryPages mocks a DynamoDB query which returns multiple pages.
+// Each of the provided queries is used as the results of a page, in order.
+// The query results include a NextPage token until the last page is reached,
+// so the mock works with Iterate() and with the Page() option.
+//
+// For example:
+//
+//	db := ddbmock.New(t)
+//	db.MockQueryPages(&listApples{Result: []Apple{{ID: "1"}}}, &listApples{Result: []Apple{{ID: "2"}}})
+//
+//	q := &listApples{}
+//	it := db.Iterate(ctx, q)
+//	for it.Next(ctx) {
+//		// q.Result contains Apple 1 on the first page, and Apple 2 on the second.
+//	}
+func (m *Client) MockQueryPages(pages ...ddb.QueryBuilder) {
+	if len(pages) == 0 {
+		m.t.Fatalf("MockQueryPages requires at least one page")
+		return
+	}
+	t := reflect.TypeOf(pages[0])
+
+	// acquire a mutex lock in case the client is being used across multiple goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.results[t] = mockResult{
+		pages: pages,
+	}
+}
+
+// MockQueryCount mocks the count returned by a DynamoDB query which uses the ddb.Count() option.
+//
+// For example:
+//
+//	db := ddbmock.New(t)
+//	db.MockQueryCount(&listApples{}, 3)
+//
+//	var n int
+//	db.Query(ctx, &listApples{}, ddb.Count(&n))
+//	// n is 3.
+func (m *Client) MockQueryCount(qb ddb.QueryBuilder, count int) {
+	t := reflect.TypeOf(qb)
+
+	// acquire a mutex lock in case the client is being used across multiple goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counts[t] = count
+}
+
 // MockQueryWithErr mocks a DynamoDB query.
 // It works the same as MockQuery, but allows an error response to be set.
 // The err argument can be nil, in which case a nil error is returned.
@@ -146,18 +379,171 @@ func (m *Client) MockQueryWithErrWithResult(qb ddb.QueryBuilder, res *ddb.QueryR
 	}
 }
 
-// Query returns mock query results based on the type of the 'qb' argument.
+// MockScan mocks a DynamoDB scan.
+// The contents of the provided scan will be used as the results of Scan().
+//
+// For example:
+//
+//	db := ddbmock.New(t)
+//	db.MockScan(&scanApples{Result: []Apple{{Color: "red"}}})
+//
+//	var got scanApples
+//	db.Scan(ctx, &got)
+//	// got now contains {Result: []Apple{{Color: "red"}}} as defined by MockScan.
+func (m *Client) MockScan(sb ddb.ScanBuilder) {
+	m.MockScanWithErr(sb, nil)
+}
+
+// MockScanWithErr mocks a DynamoDB scan.
+// It works the same as MockScan, but allows an error response to be set.
+// The error is returned by both Scan() and ScanAll().
+func (m *Client) MockScanWithErr(sb ddb.ScanBuilder, err error) {
+	t := reflect.TypeOf(sb)
+
+	// acquire a mutex lock in case the client is being used across multiple goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := m.scanResults[t]
+	res.value = sb
+	res.err = err
+	m.scanResults[t] = res
+}
+
+// MockScanAll mocks the items delivered to the callback by ScanAll() for the type of 'sb'.
+// The items argument may be a single item or a slice of items, which are marshalled to
+// DynamoDB attribute values.
+//
+// For example:
+//
+//	db := ddbmock.New(t)
+//	db.MockScanAll(&scanApples{}, []Apple{{Color: "red"}})
+func (m *Client) MockScanAll(sb ddb.ScanBuilder, items interface{}) {
+	var avs []map[string]types.AttributeValue
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		v = reflect.ValueOf([]interface{}{items})
+	}
+	for i := 0; i < v.Len(); i++ {
+		av, err := attributevalue.MarshalMap(v.Index(i).Interface())
+		if err != nil {
+			m.t.Fatalf("marshalling mock scan item: %s", err)
+			return
+		}
+		avs = append(avs, av)
+	}
+
+	t := reflect.TypeOf(sb)
+
+	// acquire a mutex lock in case the client is being used across multiple goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := m.scanResults[t]
+	res.items = avs
+	m.scanResults[t] = res
+}
+
+// Scan returns mock scan results based on the type of the 'sb' argument.
+func (m *Client) Scan(ctx context.Context, sb ddb.ScanBuilder, opts ...func(*ddb.QueryOpts)) (*ddb.ScanResult, error) {
+	t := reflect.TypeOf(sb)
+
+	m.mu.Lock()
+	got, ok := m.scanResults[t]
+	m.mu.Unlock()
+
+	if !ok || got.value == nil && got.err == nil {
+		m.t.Fatalf("no mock found for %s - call MockScan(&%s{}) to set a mock response", t, t.Elem().Name())
+		return nil, nil
+	}
+	if got.err != nil {
+		return nil, got.err
+	}
+
+	// set the value of the ScanBuilder to our stored mock result.
+	reflect.ValueOf(sb).Elem().Set(reflect.ValueOf(got.value).Elem())
+
+	return &ddb.ScanResult{}, nil
+}
+
+// ScanAll calls fn once with the items registered with MockScanAll for the type of 'sb'.
+func (m *Client) ScanAll(ctx context.Context, sb ddb.ScanBuilder, fn func(items []map[string]types.AttributeValue) error, opts ...func(*ddb.QueryOpts)) error {
+	t := reflect.TypeOf(sb)
+
+	m.mu.Lock()
+	got, ok := m.scanResults[t]
+	m.mu.Unlock()
+
+	if !ok {
+		m.t.Fatalf("no mock found for %s - call MockScanAll(&%s{}, items) to set a mock response", t, t.Elem().Name())
+		return nil
+	}
+	if got.err != nil {
+		return got.err
+	}
+	return fn(got.items)
+}
+
+// All returns mock query results based on the type of the 'qb' argument.
+// For a query mocked with MockQueryPages, the results of every page are
+// collected into the field with the `ddb:"result"` tag.
 func (m *Client) All(ctx context.Context, qb ddb.QueryBuilder, opts ...func(*ddb.QueryOpts)) error {
-	_, err := m.Query(ctx, qb, opts...)
-	if err != nil {
+	qo := ddb.QueryOpts{}
+	for _, o := range opts {
+		o(&qo)
+	}
+
+	m.mu.Lock()
+	got := m.results[reflect.TypeOf(qb)]
+	m.mu.Unlock()
+
+	if got.pages == nil || qo.Count != nil {
+		_, err := m.Query(ctx, qb, opts...)
 		return err
 	}
+
+	field := resultField(qb)
+	if !field.IsValid() {
+		return fmt.Errorf("could not find slice field with `ddb:\"result\"` tag")
+	}
+	results := reflect.MakeSlice(field.Type(), 0, 0)
+	for _, page := range got.pages {
+		results = reflect.AppendSlice(results, resultField(page))
+	}
+
+	// set the value of the QueryBuilder to the last page, with the results of every page.
+	reflect.ValueOf(qb).Elem().Set(reflect.ValueOf(got.pages[len(got.pages)-1]).Elem())
+	field.Set(results)
 	return nil
 }
 
+// resultField returns the slice field of qb with the `ddb:"result"` tag,
+// or the zero Value if there isn't one.
+func resultField(qb ddb.QueryBuilder) reflect.Value {
+	v := reflect.Indirect(reflect.ValueOf(qb))
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("ddb") == "result" && v.Field(i).Kind() == reflect.Slice {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
 // Query returns mock query results based on the type of the 'qb' argument.
 func (m *Client) Query(ctx context.Context, qb ddb.QueryBuilder, opts ...func(*ddb.QueryOpts)) (*ddb.QueryResult, error) {
 	t := reflect.TypeOf(qb)
+
+	qo := ddb.QueryOpts{}
+	for _, o := range opts {
+		o(&qo)
+	}
+	if qo.Count != nil {
+		return m.queryCount(t, qo.Count)
+	}
+
 	got, ok := m.results[t]
 	if !ok {
 		m.t.Fatalf("no mock found for %s - call RegisterQuery(&%s{}) to set a mock response", t, reflect.TypeOf(qb).Elem().Name())
@@ -169,12 +555,60 @@ func (m *Client) Query(ctx context.Context, qb ddb.QueryBuilder, opts ...func(*d
 		return nil, got.err
 	}
 
+	if got.pages != nil {
+		return m.queryPage(qb, got.pages, opts)
+	}
+
 	// set the value of the QueryBuilder to our stored mock result.
 	reflect.ValueOf(qb).Elem().Set(reflect.ValueOf(got.value).Elem())
 
 	return got.res, nil
 }
 
+// queryCount returns the count mocked with MockQueryCount.
+func (m *Client) queryCount(t reflect.Type, total *int) (*ddb.QueryResult, error) {
+	m.mu.Lock()
+	count, ok := m.counts[t]
+	m.mu.Unlock()
+	if !ok {
+		m.t.Fatalf("no mock count found for %s - call MockQueryCount(&%s{}, n) to set a mock response", t, t.Elem().Name())
+		return nil, nil
+	}
+	*total = count
+	return &ddb.QueryResult{Count: count}, nil
+}
+
+// queryPage returns a page of results mocked with MockQueryPages.
+// Page tokens are the index of the page.
+func (m *Client) queryPage(qb ddb.QueryBuilder, pages []ddb.QueryBuilder, opts []func(*ddb.QueryOpts)) (*ddb.QueryResult, error) {
+	qo := ddb.QueryOpts{}
+	for _, o := range opts {
+		o(&qo)
+	}
+	i := 0
+	if qo.PageToken != "" {
+		var err error
+		i, err = strconv.Atoi(qo.PageToken)
+		if err != nil || i < 0 || i >= len(pages) {
+			return nil, fmt.Errorf("invalid page token for mocked query: %s", qo.PageToken)
+		}
+	}
+
+	reflect.ValueOf(qb).Elem().Set(reflect.ValueOf(pages[i]).Elem())
+
+	res := &ddb.QueryResult{}
+	if i+1 < len(pages) {
+		res.NextPage = strconv.Itoa(i + 1)
+	}
+	return res, nil
+}
+
+// Iterate returns an iterator over the mocked query results.
+// Use MockQueryPages to mock a query with multiple pages.
+func (m *Client) Iterate(ctx context.Context, qb ddb.QueryBuilder, opts ...func(*ddb.QueryOpts)) *ddb.QueryIterator {
+	return ddb.NewQueryIterator(m, qb, opts...)
+}
+
 // Get returns mock query results based registered mock values.
 func (m *Client) Get(ctx context.Context, key ddb.GetKey, item ddb.Keyer, opts ...func(*ddb.GetOpts)) (*ddb.GetItemResult, error) {
 	got, ok := m.getResults[key]
@@ -189,33 +623,161 @@ func (m *Client) Get(ctx context.Context, key ddb.GetKey, item ddb.Keyer, opts .
 	}
 
 	// set the value of the item to our stored mock result.
-	reflect.ValueOf(item).Elem().Set(reflect.ValueOf(got.value).Elem())
+	reflect.ValueOf(ddb.Unwrap(item)).Elem().Set(reflect.Indirect(reflect.ValueOf(got.value)))
 
 	return got.res, nil
 }
 
-func (m *Client) Put(ctx context.Context, item ddb.Keyer) error {
-	return m.PutErr
+// QueryOne returns the item or error registered with MockQueryOne or MockQueryOneWithErr.
+func (m *Client) QueryOne(ctx context.Context, qb ddb.QueryBuilder, item ddb.Keyer, opts ...func(*ddb.QueryOpts)) error {
+	t := reflect.TypeOf(qb)
+	m.mu.Lock()
+	got, ok := m.queryOneResults[t]
+	m.mu.Unlock()
+	if !ok {
+		m.t.Fatalf("no mock found for %s - call MockQueryOne() to set a mock response", t)
+		return nil
+	}
+	if got.err != nil {
+		return got.err
+	}
+
+	reflect.ValueOf(ddb.Unwrap(item)).Elem().Set(reflect.Indirect(reflect.ValueOf(got.value)))
+	return nil
+}
+
+// GetByIndex returns the item or error registered with MockGetByIndex or MockGetByIndexWithErr.
+func (m *Client) GetByIndex(ctx context.Context, index string, pk string, sk string, item ddb.Keyer) error {
+	m.mu.Lock()
+	got, ok := m.indexResults[indexKey{index: index, pk: pk, sk: sk}]
+	m.mu.Unlock()
+	if !ok {
+		m.t.Fatalf("no mock found for index %s with keys %q, %q - call MockGetByIndex() to set a mock response", index, pk, sk)
+		return nil
+	}
+	if got.err != nil {
+		return got.err
+	}
+
+	reflect.ValueOf(ddb.Unwrap(item)).Elem().Set(reflect.Indirect(reflect.ValueOf(got.value)))
+	return nil
+}
+
+func (m *Client) Put(ctx context.Context, item ddb.Keyer, opts ...func(*ddb.WriteOpts)) error {
+	if m.PutErr != nil {
+		return m.PutErr
+	}
+	return m.checkCondition(item, opts)
+}
+
+// Update returns the error set with UpdateErr, or ddb.ErrConditionFailed if the
+// update is conditional and the key has been registered with MockConditionFailed.
+// The item passed to ddb.ReturnNew() or ddb.ReturnOld() is set to the result
+// registered with MockUpdate.
+func (m *Client) Update(ctx context.Context, key ddb.GetKey, ub ddb.UpdateBuilder, opts ...func(*ddb.WriteOpts)) (*ddb.UpdateItemResult, error) {
+	if m.UpdateErr != nil {
+		return nil, m.UpdateErr
+	}
+	if err := m.checkKeyCondition(key, opts); err != nil {
+		return nil, err
+	}
+
+	wo := ddb.WriteOpts{}
+	for _, o := range opts {
+		o(&wo)
+	}
+
+	m.mu.Lock()
+	result, ok := m.updateResults[key]
+	m.mu.Unlock()
+
+	if ok && wo.ReturnItem != nil {
+		// set the value of the item to our stored mock result.
+		reflect.ValueOf(ddb.Unwrap(wo.ReturnItem)).Elem().Set(reflect.Indirect(reflect.ValueOf(result)))
+	}
+
+	return &ddb.UpdateItemResult{}, nil
+}
+
+// GetBatch returns the results registered with MockGet for each key.
+// Keys without a mocked result are reported as missing, so
+// a *ddb.MissingKeysError is returned if any keys haven't been mocked.
+func (m *Client) GetBatch(ctx context.Context, keys []ddb.GetKey, out interface{}, opts ...func(*ddb.GetOpts)) (*ddb.GetBatchResult, error) {
+	if m.GetBatchErr != nil {
+		return nil, m.GetBatchErr
+	}
+
+	outVal := reflect.ValueOf(out).Elem()
+	if outVal.Kind() == reflect.Map {
+		if outVal.IsNil() {
+			outVal.Set(reflect.MakeMap(outVal.Type()))
+		}
+	} else {
+		outVal.Set(reflect.MakeSlice(outVal.Type(), 0, len(keys)))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := &ddb.GetBatchResult{}
+	for _, k := range keys {
+		got, ok := m.getResults[k]
+		if !ok {
+			res.Missing = append(res.Missing, k)
+			continue
+		}
+		if got.err != nil {
+			return nil, got.err
+		}
+		v := reflect.Indirect(reflect.ValueOf(got.value))
+		if outVal.Kind() == reflect.Map {
+			outVal.SetMapIndex(reflect.ValueOf(k), v)
+		} else {
+			outVal.Set(reflect.Append(outVal, v))
+		}
+	}
+	if len(res.Missing) > 0 {
+		return res, &ddb.MissingKeysError{Keys: res.Missing}
+	}
+	return res, nil
 }
 
 func (m *Client) PutBatch(ctx context.Context, items ...ddb.Keyer) error {
 	return m.PutBatchErr
 }
 
-func (m *Client) TransactWriteItems(ctx context.Context, tx []ddb.TransactWriteItem) error {
+func (m *Client) TransactWriteItems(ctx context.Context, tx []ddb.TransactWriteItem, opts ...func(*ddb.TransactionOpts)) error {
 	return m.TransactWriteItemsErr
 }
 
-func (m *Client) Delete(ctx context.Context, item ddb.Keyer) error {
-	return m.DeleteErr
+func (m *Client) Delete(ctx context.Context, item ddb.Keyer, opts ...func(*ddb.WriteOpts)) error {
+	if m.DeleteErr != nil {
+		return m.DeleteErr
+	}
+	return m.checkCondition(item, opts)
 }
 
 func (m *Client) DeleteBatch(ctx context.Context, items ...ddb.Keyer) error {
 	return m.DeleteBatchErr
 }
 
-func (m *Client) NewTransaction() ddb.Transaction {
-	return &MockTransaction{ExecuteError: m.TransactionExecuteErr}
+func (m *Client) NewTransaction(opts ...func(*ddb.TransactionOpts)) ddb.Transaction {
+	tx := &MockTransaction{ExecuteError: m.TransactionExecuteErr, ValidateError: m.TransactionValidateErr}
+	for _, o := range opts {
+		o(&tx.Opts)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transactions = append(m.transactions, tx)
+	return tx
+}
+
+// Transactions returns the transactions created by NewTransaction(), in the order they were created.
+// The operations added to each transaction are recorded in its Items field.
+func (m *Client) Transactions() []*MockTransaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.transactions
 }
 
 // Client returns nil. If you're writing tests which use
@@ -225,6 +787,13 @@ func (m *Client) Client() *dynamodb.Client {
 	return nil
 }
 
+// API returns nil. If you're writing tests which use
+// DynamoDB implementation details you should probably be
+// using integration tests!
+func (m *Client) API() ddb.DynamoDBAPI {
+	return nil
+}
+
 func (m *Client) Table() string {
 	return ""
 }
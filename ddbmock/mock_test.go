@@ -4,7 +4,9 @@ import (
 	"context"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/common-fate/ddb"
 	"github.com/stretchr/testify/assert"
 )
@@ -172,3 +174,266 @@ func TestMockGet(t *testing.T) {
 		})
 	}
 }
+
+func TestMockConditionFailed(t *testing.T) {
+	ctx := context.Background()
+	m := New(&mockTestReporter{})
+	m.MockConditionFailed(ddb.GetKey{PK: "PK", SK: "SK"})
+
+	// unconditional writes aren't affected.
+	err := m.Put(ctx, thing{})
+	assert.NoError(t, err)
+
+	err = m.Put(ctx, thing{}, ddb.IfNotExists())
+	assert.Equal(t, ddb.ErrConditionFailed, err)
+
+	err = m.Delete(ctx, thing{}, ddb.Condition("attribute_exists(ID)", nil, nil))
+	assert.Equal(t, ddb.ErrConditionFailed, err)
+}
+
+func TestMockUpdate(t *testing.T) {
+	ctx := context.Background()
+	m := New(&mockTestReporter{})
+	key := ddb.GetKey{PK: "PK", SK: "SK"}
+	m.MockUpdate(key, thing{ID: "updated"})
+
+	var got thing
+	_, err := m.Update(ctx, key, ddb.NewUpdate().Set("ID", "updated"), ddb.ReturnNew(&got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, thing{ID: "updated"}, got)
+
+	m.MockConditionFailed(key)
+	_, err = m.Update(ctx, key, ddb.NewUpdate().Set("ID", "updated"), ddb.IfExists())
+	assert.Equal(t, ddb.ErrConditionFailed, err)
+}
+
+func TestMockGetBatch(t *testing.T) {
+	ctx := context.Background()
+	m := New(&mockTestReporter{})
+	m.MockGet(ddb.GetKey{PK: "1", SK: "1"}, &thing{ID: "1"})
+	m.MockGet(ddb.GetKey{PK: "2", SK: "2"}, &thing{ID: "2"})
+
+	var got []thing
+	_, err := m.GetBatch(ctx, []ddb.GetKey{{PK: "2", SK: "2"}, {PK: "1", SK: "1"}}, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{ID: "2"}, {ID: "1"}}, got)
+
+	var byKey map[ddb.GetKey]thing
+	_, err = m.GetBatch(ctx, []ddb.GetKey{{PK: "1", SK: "1"}, {PK: "3", SK: "3"}}, &byKey)
+	assert.Equal(t, &ddb.MissingKeysError{Keys: []ddb.GetKey{{PK: "3", SK: "3"}}}, err)
+	assert.Equal(t, map[ddb.GetKey]thing{{PK: "1", SK: "1"}: {ID: "1"}}, byKey)
+}
+
+type testScan struct {
+	Result []thing `ddb:"result"`
+}
+
+func (s *testScan) BuildScan() (*dynamodb.ScanInput, error) {
+	return &dynamodb.ScanInput{}, nil
+}
+
+func TestMockScan(t *testing.T) {
+	ctx := context.Background()
+	m := New(&mockTestReporter{})
+	m.MockScan(&testScan{Result: []thing{{ID: "1"}}})
+	m.MockScanAll(&testScan{}, []thing{{ID: "1"}, {ID: "2"}})
+
+	got := &testScan{}
+	_, err := m.Scan(ctx, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{ID: "1"}}, got.Result)
+
+	var all []thing
+	err = m.ScanAll(ctx, &testScan{}, func(items []map[string]types.AttributeValue) error {
+		return attributevalue.UnmarshalListOfMaps(items, &all)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{ID: "1"}, {ID: "2"}}, all)
+
+	m.MockScanWithErr(&testScan{}, ddb.ErrNoItems)
+	_, err = m.Scan(ctx, got)
+	assert.Equal(t, ddb.ErrNoItems, err)
+}
+
+type listThings struct {
+	Result []thing `ddb:"result"`
+}
+
+func (l *listThings) BuildQuery() (*dynamodb.QueryInput, error) {
+	return &dynamodb.QueryInput{}, nil
+}
+
+func TestMockQueryPages(t *testing.T) {
+	ctx := context.Background()
+	m := New(&mockTestReporter{})
+	m.MockQueryPages(
+		&listThings{Result: []thing{{ID: "1"}}},
+		&listThings{Result: []thing{{ID: "2"}, {ID: "3"}}},
+	)
+
+	q := &listThings{}
+	it := m.Iterate(ctx, q)
+	var got [][]thing
+	for it.Next(ctx) {
+		got = append(got, q.Result)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, [][]thing{{{ID: "1"}}, {{ID: "2"}, {ID: "3"}}}, got)
+
+	// pages can also be requested directly.
+	res, err := m.Query(ctx, q, ddb.Page("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, res.NextPage)
+	assert.Equal(t, []thing{{ID: "2"}, {ID: "3"}}, q.Result)
+}
+
+func TestMockAllQueryPages(t *testing.T) {
+	ctx := context.Background()
+	m := New(t)
+	m.MockQueryPages(
+		&listThings{Result: []thing{{ID: "1"}}},
+		&listThings{Result: []thing{{ID: "2"}, {ID: "3"}}},
+	)
+
+	q := &listThings{}
+	err := m.All(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{ID: "1"}, {ID: "2"}, {ID: "3"}}, q.Result)
+
+	got, err := ddb.AllAs[thing](ctx, m, &listThings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{ID: "1"}, {ID: "2"}, {ID: "3"}}, got)
+}
+
+func TestMockQueryCount(t *testing.T) {
+	ctx := context.Background()
+	m := New(t)
+	m.MockQueryCount(&listThings{}, 3)
+
+	var n int
+	res, err := m.Query(ctx, &listThings{}, ddb.Count(&n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 3, res.Count)
+
+	n = 0
+	err = m.All(ctx, &listThings{}, ddb.Count(&n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, n)
+}
+
+func TestMockTyped(t *testing.T) {
+	ctx := context.Background()
+	m := New(t)
+	m.MockGet(ddb.GetKey{PK: "A", SK: "1"}, thing{ID: "1"})
+	m.MockQueryPages(&listThings{Result: []thing{{ID: "1"}}}, &listThings{Result: []thing{{ID: "2"}}})
+
+	got, err := ddb.GetAs[thing](ctx, m, ddb.GetKey{PK: "A", SK: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, thing{ID: "1"}, got)
+
+	things := ddb.NewRepository[thing](m)
+	page, next, err := things.Query(ctx, &listThings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{ID: "1"}}, page)
+	assert.Equal(t, "1", next)
+
+	page, next, err = ddb.QueryAs[thing](ctx, m, &listThings{}, ddb.Page(next))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []thing{{ID: "2"}}, page)
+	assert.Empty(t, next)
+}
+
+func TestMockGetByIndex(t *testing.T) {
+	ctx := context.Background()
+	m := New(t)
+	m.MockGetByIndex("GSI1", "red", "", thing{ID: "1"})
+	m.MockGetByIndexWithErr("GSI1", "green", "", ddb.ErrMultipleItems)
+
+	var got thing
+	err := m.GetByIndex(ctx, "GSI1", "red", "", &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, thing{ID: "1"}, got)
+
+	err = m.GetByIndex(ctx, "GSI1", "green", "", &got)
+	assert.ErrorIs(t, err, ddb.ErrMultipleItems)
+
+	tr := &mockTestReporter{}
+	m = New(tr)
+	_ = m.GetByIndex(ctx, "GSI1", "blue", "", &got)
+	assert.Len(t, tr.Logs, 1)
+}
+
+func TestMockQueryOne(t *testing.T) {
+	ctx := context.Background()
+	m := New(t)
+	m.MockQueryOne(&listThings{}, thing{ID: "1"})
+
+	var got thing
+	err := m.QueryOne(ctx, &listThings{}, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, thing{ID: "1"}, got)
+
+	m.MockQueryOneWithErr(&listThings{}, &ddb.MultipleItemsError{Count: 3})
+	err = m.QueryOne(ctx, &listThings{}, &got)
+	assert.ErrorIs(t, err, ddb.ErrMultipleItems)
+
+	tr := &mockTestReporter{}
+	m = New(tr)
+	_ = m.QueryOne(ctx, &listThings{}, &got)
+	assert.Len(t, tr.Logs, 1)
+}
+
+func TestMockTransaction(t *testing.T) {
+	ctx := context.Background()
+	m := New(t)
+
+	tx := m.NewTransaction(ddb.RetryOnConflict(3))
+	tx.ConditionCheck(ddb.GetKey{PK: "PARENT", SK: "1"}, ddb.IfExists())
+	tx.Put(thing{ID: "1"}, ddb.IfNotExists())
+	tx.Delete(thing{ID: "2"})
+	err := tx.Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txs := m.Transactions()
+	assert.Len(t, txs, 1)
+	assert.Equal(t, ddb.TransactionOpts{MaxConflictRetries: 3}, txs[0].Opts)
+	assert.True(t, txs[0].Executed)
+	assert.Equal(t, []ddb.TransactWriteItem{
+		{ConditionCheck: &ddb.GetKey{PK: "PARENT", SK: "1"}, Opts: ddb.WriteOpts{IfExists: true}},
+		{Put: thing{ID: "1"}, Opts: ddb.WriteOpts{IfNotExists: true}},
+		{Delete: thing{ID: "2"}},
+	}, txs[0].Items)
+}
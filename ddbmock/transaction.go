@@ -2,19 +2,60 @@ package ddbmock
 
 import (
 	"context"
+	"sync"
 
 	"github.com/common-fate/ddb"
 )
 
+// MockTransaction records the operations added to it,
+// so that tests can assert on what a transaction would write.
 type MockTransaction struct {
 	// ExecuteError causes Execute() to return with an error if set
 	ExecuteError error
+	// ValidateError causes Validate() to return with an error if set
+	ValidateError error
+	// Items are the operations added to the transaction, in the order they were added.
+	Items []ddb.TransactWriteItem
+	// Executed is true if Execute() has been called.
+	Executed bool
+	// Opts are the options the transaction was created with.
+	Opts ddb.TransactionOpts
+
+	mu sync.Mutex
 }
 
 func (m *MockTransaction) Execute(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Executed = true
 	return m.ExecuteError
 }
 
-func (m *MockTransaction) Put(item ddb.Keyer) {}
+func (m *MockTransaction) Validate() error {
+	return m.ValidateError
+}
+
+func (m *MockTransaction) Put(item ddb.Keyer, opts ...func(*ddb.WriteOpts)) {
+	m.add(ddb.TransactWriteItem{Put: item}, opts)
+}
+
+func (m *MockTransaction) Delete(item ddb.Keyer, opts ...func(*ddb.WriteOpts)) {
+	m.add(ddb.TransactWriteItem{Delete: item}, opts)
+}
 
-func (m *MockTransaction) Delete(item ddb.Keyer) {}
+func (m *MockTransaction) Update(key ddb.GetKey, ub ddb.UpdateBuilder, opts ...func(*ddb.WriteOpts)) {
+	m.add(ddb.TransactWriteItem{Update: &ddb.UpdateItem{Key: key, Update: ub}}, opts)
+}
+
+func (m *MockTransaction) ConditionCheck(key ddb.GetKey, opts ...func(*ddb.WriteOpts)) {
+	m.add(ddb.TransactWriteItem{ConditionCheck: &key}, opts)
+}
+
+func (m *MockTransaction) add(item ddb.TransactWriteItem, opts []func(*ddb.WriteOpts)) {
+	for _, o := range opts {
+		o(&item.Opts)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Items = append(m.Items, item)
+}
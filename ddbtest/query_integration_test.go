@@ -105,6 +105,26 @@ func (l *ListThingGSI) BuildQuery() (*dynamodb.QueryInput, error) {
 	return &qi, nil
 }
 
+// ListThingExpression builds its query with ddb.Q().
+type ListThingExpression struct {
+	Type   string
+	Result []Thing `ddb:"result"`
+}
+
+func (l *ListThingExpression) BuildQuery() (*dynamodb.QueryInput, error) {
+	return ddb.Q().PK(l.Type).BuildQuery()
+}
+
+// ListThingExpressionGSI builds a query on a GSI with ddb.Q().
+type ListThingExpressionGSI struct {
+	Type   string
+	Result []Thing `ddb:"result"`
+}
+
+func (l *ListThingExpressionGSI) BuildQuery() (*dynamodb.QueryInput, error) {
+	return ddb.Q().Index("GSI1").PK(l.Type).BuildQuery()
+}
+
 func randomThings(t string, count int) []Thing {
 	var apples []Thing
 	for i := 0; i < count; i++ {
@@ -146,6 +166,16 @@ func TestQueryIntegration(t *testing.T) {
 			Query: &ListThingsCustomUnmarshal{Type: typ},
 			Want:  &ListThingsCustomUnmarshal{Type: typ, Result: apples},
 		},
+		{
+			Name:  "query expression",
+			Query: &ListThingExpression{Type: typ},
+			Want:  &ListThingExpression{Type: typ, Result: apples},
+		},
+		{
+			Name:  "query expression gsi",
+			Query: &ListThingExpressionGSI{Type: typ},
+			Want:  &ListThingExpressionGSI{Type: typ, Result: apples},
+		},
 	}
 	RunQueryTests(t, c, testcases)
 }
@@ -192,6 +222,13 @@ func TestPaginationIntegration(t *testing.T) {
 			WantPage1: &ListThingsCustomUnmarshal{Type: typ, Result: apples[0:1]},
 			WantPage2: &ListThingsCustomUnmarshal{Type: typ, Result: apples[1:2]},
 		},
+		{
+			Name:      "pagination query expression gsi",
+			Query:     &ListThingExpressionGSI{Type: typ},
+			PageSize:  1,
+			WantPage1: &ListThingExpressionGSI{Type: typ, Result: apples[0:1]},
+			WantPage2: &ListThingExpressionGSI{Type: typ, Result: apples[1:2]},
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.Name, func(t *testing.T) {
@@ -3,40 +3,81 @@ package ddb
 import (
 	"context"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 // Delete calls DeleteItem to delete an item in DynamoDB.
-func (c *Client) Delete(ctx context.Context, item Keyer) error {
+//
+// Delete can be made conditional by providing options such as IfExists() or Condition().
+// If the condition is not met, ErrConditionFailed is returned.
+//
+// If the item is Versioned, the delete only succeeds if the stored version matches
+// the item's version, and ErrVersionConflict is returned otherwise. If the write also
+// has other conditions, ErrConditionFailed is returned instead, as DynamoDB doesn't
+// report which of the conditions failed.
+//
+// To read the item which was deleted, provide ReturnOld(&item).
+func (c *Client) Delete(ctx context.Context, item Keyer, opts ...func(*WriteOpts)) error {
+	wo := WriteOpts{}
+	for _, o := range opts {
+		o(&wo)
+	}
+
+	if err := wo.checkOldReturnValues("Delete"); err != nil {
+		return err
+	}
+
 	keys, err := item.DDBKeys()
 	if err != nil {
 		return err
 	}
 
-	keyAttrs, err := attributevalue.MarshalMap(keys)
+	condition := wo.buildCondition(c.schema)
+
+	version, err := versionOf(item)
 	if err != nil {
 		return err
 	}
+	if version != nil {
+		condition = condition.and(version.condition())
+	}
 
-	_, err = c.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		Key: map[string]types.AttributeValue{
-			"PK": keyAttrs["PK"],
-			"SK": keyAttrs["SK"],
-		},
-		TableName: &c.table,
+	expr, names, values := conditionArgs(condition)
+
+	out, err := c.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		Key:                       c.schema.key(GetKey{PK: keys.PK, SK: keys.SK}),
+		TableName:                 &c.table,
+		ConditionExpression:       expr,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              wo.ReturnValues,
 	})
-	return err
+	if err != nil {
+		return conditionError(err, version, wo)
+	}
+	return wo.unmarshalReturnValues(out.Attributes, c.schema)
 }
 
-// DeleteBatch calls BatchWriteItem to create or update items in DynamoDB.
+// DeleteBatch calls BatchWriteItem to delete items in DynamoDB.
 //
 // DynamoDB BatchWriteItem api has a limit of 25 items per batch.
 // DeleteBatch will automatically split the items into batches of 25 by default.
 //
 // You can override this default batch size using WithBatchSize(n) when you initialize the client.
+// To write batches in parallel, use WithBatchConcurrency(n).
+//
+// Items which DynamoDB doesn't process, for example due to throttling, are retried
+// with exponential backoff. If items are still unprocessed after retrying,
+// a *BatchWriteError listing them is returned. A *BatchWriteError is also returned
+// if any batches fail, listing the batches and their errors.
+//
+// BatchWriteItem doesn't support conditions, so DeleteBatch returns an error
+// without deleting anything if any of the items are Versioned.
 func (c *Client) DeleteBatch(ctx context.Context, items ...Keyer) error {
+	if err := checkNotVersioned("DeleteBatch", items); err != nil {
+		return err
+	}
 	wr := make([]types.WriteRequest, len(items))
 	for i, item := range items {
 		keys, err := item.DDBKeys()
@@ -44,32 +85,11 @@ func (c *Client) DeleteBatch(ctx context.Context, items ...Keyer) error {
 			return err
 		}
 
-		keyAttrs, err := attributevalue.MarshalMap(keys)
-		if err != nil {
-			return err
-		}
 		wr[i] = types.WriteRequest{
 			DeleteRequest: &types.DeleteRequest{
-				Key: map[string]types.AttributeValue{
-					"PK": keyAttrs["PK"],
-					"SK": keyAttrs["SK"],
-				},
+				Key: c.schema.key(GetKey{PK: keys.PK, SK: keys.SK}),
 			},
 		}
 	}
-	for i := 0; i < len(wr); i += c.batchSize {
-		end := len(wr)
-		if i+c.batchSize < end {
-			end = i + c.batchSize
-		}
-		_, err := c.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]types.WriteRequest{
-				c.table: wr[i:end],
-			},
-		})
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return c.batchWrite(ctx, wr, items)
 }
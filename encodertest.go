@@ -21,6 +21,27 @@ var encoderTestCases = []encoderTestCase{
 			"SK": &types.AttributeValueMemberS{Value: "2"},
 		},
 	},
+	{
+		name: "number",
+		give: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "1"},
+			"SK": &types.AttributeValueMemberN{Value: "1672531200"},
+		},
+	},
+	{
+		name: "binary",
+		give: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberB{Value: []byte{0x00, 0xff, 0x10}},
+			"SK": &types.AttributeValueMemberN{Value: "-1.5"},
+		},
+	},
+	{
+		name: "string which looks like a number",
+		give: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "10"},
+			"SK": &types.AttributeValueMemberS{Value: ""},
+		},
+	},
 	{
 		name: "empty",
 		give: nil,
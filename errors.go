@@ -6,5 +6,38 @@ import "errors"
 // but it doesn't contain any.
 var ErrNoItems error = errors.New("item query returned no items")
 
+// ErrMultipleItems is returned when we expect a query result to contain a single item,
+// but it contains more than one. QueryOne returns a *MultipleItemsError, which
+// matches ErrMultipleItems when used with errors.Is.
+var ErrMultipleItems error = errors.New("item query returned more than one item")
+
 // ErrInvalidBatchSize is returned if an invalid batch size is specified when creating a ddb instance.
 var ErrInvalidBatchSize error = errors.New("batch size must be greater than 0 and must not be greater than 25")
+
+// ErrInvalidBatchRetries is returned if a negative number of batch retries is specified when creating a ddb instance.
+var ErrInvalidBatchRetries error = errors.New("batch retries must not be negative")
+
+// ErrInvalidBatchConcurrency is returned if a batch concurrency of less than 1 is specified when creating a ddb instance.
+var ErrInvalidBatchConcurrency error = errors.New("batch concurrency must be greater than 0")
+
+// ErrConditionFailed is returned when a conditional write is rejected
+// because its condition was not met.
+var ErrConditionFailed error = errors.New("the conditional request failed")
+
+// ErrVersionConflict is returned when a write to a versioned item is rejected
+// because the item has been modified since it was read.
+var ErrVersionConflict error = errors.New("the item has been modified by another writer: version conflict")
+
+// ErrUnknownEntityType is returned when an item is read into ddb.Entities,
+// but its entity type hasn't been registered with ddb.Register.
+var ErrUnknownEntityType error = errors.New("entity type is not registered")
+
+// ErrReadLimitExceeded is returned by All if the query returns more
+// items than the limit set with MaxItems(), or if a page token is still
+// present after reading the number of pages set with MaxPages().
+var ErrReadLimitExceeded error = errors.New("query exceeded the maximum number of items or pages to read")
+
+// ErrInvalidTransaction is returned if a transaction is rejected before calling the
+// TransactWriteItems API, for example because it has more than one operation on the same item.
+// The error describes the operations which caused the transaction to be rejected.
+var ErrInvalidTransaction error = errors.New("invalid transaction")
@@ -34,6 +34,12 @@ func (l *ListCarAndWheelsByColor) BuildQuery() (*dynamodb.QueryInput, error) {
 }
 
 func (l *ListCarAndWheelsByColor) UnmarshalQueryOutput(out *dynamodb.QueryOutput) error {
+	l.Result = Result{}
+	return l.AppendQueryOutput(out)
+}
+
+// AppendQueryOutput allows the query to be used with All(), which calls it for each page of results.
+func (l *ListCarAndWheelsByColor) AppendQueryOutput(out *dynamodb.QueryOutput) error {
 	// an example of custom unmarshalling logic for complex queries which return multiple item types
 	for _, item := range out.Items {
 		typeField, ok := item["type"].(*types.AttributeValueMemberS)
@@ -68,4 +74,7 @@ func Example_customUnmarshalling() {
 	_, _ = c.Query(ctx, &q, nil)
 
 	// q.Result.Car and q.Result.Wheels are now populated with data as fetched from DynamoDB
+
+	// All() fetches every page, calling AppendQueryOutput for each one.
+	_ = c.All(ctx, &q)
 }
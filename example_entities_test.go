@@ -0,0 +1,61 @@
+package ddb_test
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/common-fate/ddb"
+)
+
+type Invoice struct {
+	ID    string   `ddb:"pk,template=INVOICE#{ID}"`
+	_     struct{} `ddb:"sk,template=INVOICE"`
+	Total int
+}
+
+type LineItem struct {
+	InvoiceID string `ddb:"pk,template=INVOICE#{InvoiceID}"`
+	Line      int    `ddb:"sk,template=LINE#{Line}"`
+	Amount    int
+}
+
+func init() {
+	// registered types are written with a 'ddb:type' attribute, which is used
+	// to unmarshal them into the right Go type when they are read back.
+	ddb.Register[Invoice]("invoice")
+	ddb.Register[LineItem]("lineItem")
+}
+
+type GetInvoiceWithLineItems struct {
+	ID     string
+	Result ddb.Entities `ddb:"result"`
+}
+
+func (g *GetInvoiceWithLineItems) BuildQuery() (*dynamodb.QueryInput, error) {
+	return ddb.Q().PK("INVOICE#" + g.ID).BuildQuery()
+}
+
+// For queries which return items of more than one type, register each type and
+// use a ddb.Entities result field. Each item is unmarshalled into its registered type.
+func Example_entities() {
+	ctx := context.TODO()
+
+	c, _ := ddb.New(ctx, "example-table")
+	_ = c.Put(ctx, ddb.Tagged(&Invoice{ID: "1", Total: 100}))
+	_ = c.Put(ctx, ddb.Tagged(&LineItem{InvoiceID: "1", Line: 1, Amount: 100}))
+
+	q := GetInvoiceWithLineItems{ID: "1"}
+	_ = c.All(ctx, &q)
+
+	for _, e := range q.Result {
+		switch v := e.(type) {
+		case Invoice:
+			_ = v.Total
+		case LineItem:
+			_ = v.Amount
+		}
+	}
+
+	// or, to get the line items only:
+	_ = ddb.EntitiesOf[LineItem](q.Result)
+}
@@ -30,3 +30,33 @@ func Example_transaction() {
 	tx.Delete(Item{ID: "3"})
 	_ = tx.Execute(ctx)
 }
+
+// Transactions can include conditions, which must be met for any of the writes to be made.
+func Example_transactionConditions() {
+	ctx := context.TODO()
+
+	c, _ := ddb.New(ctx, "example-table")
+	tx := c.NewTransaction()
+
+	// the item with ID 1 must exist.
+	tx.ConditionCheck(ddb.GetKey{PK: "1", SK: "SK"}, ddb.IfExists())
+	// the item with ID 2 must not already exist.
+	tx.Put(Item{ID: "2"}, ddb.IfNotExists())
+	_ = tx.Execute(ctx)
+}
+
+// Transactions can be made idempotent with a client request token, which is reused
+// when the transaction is retried after conflicting with another transaction.
+func Example_transactionRetries() {
+	ctx := context.TODO()
+
+	c, _ := ddb.New(ctx, "example-table")
+
+	// store the token to safely retry the transaction later,
+	// for example if the network fails.
+	token, _ := ddb.NewClientRequestToken()
+	tx := c.NewTransaction(ddb.ClientRequestToken(token), ddb.RetryOnConflict(3))
+
+	tx.Put(Item{ID: "1"})
+	_ = tx.Execute(ctx)
+}
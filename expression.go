@@ -0,0 +1,332 @@
+package ddb
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// conditionCompiler validates a condition expression such as
+// 'Status = ? AND size(Tags) > ?' and rewrites it to use placeholders.
+//
+// Every attribute name is replaced with a name placeholder, so reserved
+// words can be used as attribute names, and every '?' is replaced with a
+// value placeholder for the next argument.
+type conditionCompiler struct {
+	expr   string
+	tokens []exprToken
+	pos    int
+	args   []interface{}
+	ph     *placeholders
+}
+
+type exprTokenKind int
+
+const (
+	tokenIdent exprTokenKind = iota
+	tokenArg
+	tokenSymbol
+	tokenEOF
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	text string
+}
+
+// compileCondition compiles a condition expression, adding its attribute
+// names and values to ph.
+func compileCondition(expr string, args []interface{}, ph *placeholders) (string, error) {
+	tokens, err := lexCondition(expr)
+	if err != nil {
+		return "", err
+	}
+	c := &conditionCompiler{expr: expr, tokens: tokens, args: args, ph: ph}
+	out, err := c.or()
+	if err != nil {
+		return "", err
+	}
+	if t := c.peek(); t.kind != tokenEOF {
+		return "", c.errorf("unexpected %q", t.text)
+	}
+	if len(c.args) > 0 {
+		return "", fmt.Errorf("expression %q has %d more arguments than placeholders", expr, len(c.args))
+	}
+	return out, nil
+}
+
+func lexCondition(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	rs := []rune(expr)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '?':
+			tokens = append(tokens, exprToken{kind: tokenArg, text: "?"})
+			i++
+		case strings.ContainsRune("(),=", r):
+			tokens = append(tokens, exprToken{kind: tokenSymbol, text: string(r)})
+			i++
+		case r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(rs) && (rs[i+1] == '=' || (r == '<' && rs[i+1] == '>')) {
+				op += string(rs[i+1])
+			}
+			tokens = append(tokens, exprToken{kind: tokenSymbol, text: op})
+			i += len(op)
+		case r == ':' || r == '#':
+			// names may contain ':', such as 'ddb:version', but can't start with it.
+			return nil, fmt.Errorf("invalid expression %q: use attribute names and '?' rather than %q placeholders", expr, r)
+		case isPathRune(r):
+			start := i
+			for i < len(rs) && isPathRune(rs[i]) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: string(rs[start:i])})
+		default:
+			return nil, fmt.Errorf("invalid expression %q: unexpected %q", expr, r)
+		}
+	}
+	return append(tokens, exprToken{kind: tokenEOF}), nil
+}
+
+func isPathRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-.[]:", r)
+}
+
+func (c *conditionCompiler) peek() exprToken {
+	return c.tokens[c.pos]
+}
+
+func (c *conditionCompiler) next() exprToken {
+	t := c.tokens[c.pos]
+	if t.kind != tokenEOF {
+		c.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the keyword kw.
+func (c *conditionCompiler) keyword(kw string) bool {
+	t := c.peek()
+	if t.kind == tokenIdent && strings.EqualFold(t.text, kw) {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+func (c *conditionCompiler) symbol(s string) error {
+	if t := c.next(); t.kind != tokenSymbol || t.text != s {
+		return c.errorf("expected %q", s)
+	}
+	return nil
+}
+
+func (c *conditionCompiler) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid expression %q: %s", c.expr, fmt.Sprintf(format, args...))
+}
+
+func (c *conditionCompiler) or() (string, error) {
+	left, err := c.and()
+	if err != nil {
+		return "", err
+	}
+	for c.keyword("OR") {
+		right, err := c.and()
+		if err != nil {
+			return "", err
+		}
+		left += " OR " + right
+	}
+	return left, nil
+}
+
+func (c *conditionCompiler) and() (string, error) {
+	left, err := c.not()
+	if err != nil {
+		return "", err
+	}
+	for c.keyword("AND") {
+		right, err := c.not()
+		if err != nil {
+			return "", err
+		}
+		left += " AND " + right
+	}
+	return left, nil
+}
+
+func (c *conditionCompiler) not() (string, error) {
+	if c.keyword("NOT") {
+		cond, err := c.not()
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + cond, nil
+	}
+	return c.primary()
+}
+
+// conditionFunctions are the functions which can be used as conditions.
+var conditionFunctions = map[string]bool{
+	"attribute_exists":     true,
+	"attribute_not_exists": true,
+	"attribute_type":       true,
+	"begins_with":          true,
+	"contains":             true,
+}
+
+func (c *conditionCompiler) primary() (string, error) {
+	t := c.peek()
+	if t.kind == tokenSymbol && t.text == "(" {
+		c.next()
+		cond, err := c.or()
+		if err != nil {
+			return "", err
+		}
+		if err := c.symbol(")"); err != nil {
+			return "", err
+		}
+		return "(" + cond + ")", nil
+	}
+	if t.kind == tokenIdent && conditionFunctions[t.text] {
+		return c.function()
+	}
+
+	left, err := c.operand()
+	if err != nil {
+		return "", err
+	}
+	if c.keyword("BETWEEN") {
+		low, err := c.operand()
+		if err != nil {
+			return "", err
+		}
+		if !c.keyword("AND") {
+			return "", c.errorf("expected AND in BETWEEN condition")
+		}
+		high, err := c.operand()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s BETWEEN %s AND %s", left, low, high), nil
+	}
+	if c.keyword("IN") {
+		if err := c.symbol("("); err != nil {
+			return "", err
+		}
+		var list []string
+		for {
+			o, err := c.operand()
+			if err != nil {
+				return "", err
+			}
+			list = append(list, o)
+			if c.peek().text != "," {
+				break
+			}
+			c.next()
+		}
+		if err := c.symbol(")"); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s IN (%s)", left, strings.Join(list, ", ")), nil
+	}
+
+	op := c.next()
+	switch op.text {
+	case "=", "<>", "<", "<=", ">", ">=":
+	default:
+		return "", c.errorf("expected a comparison after %q", left)
+	}
+	right, err := c.operand()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s %s", left, op.text, right), nil
+}
+
+func (c *conditionCompiler) function() (string, error) {
+	name := c.next().text
+	if err := c.symbol("("); err != nil {
+		return "", err
+	}
+	path, err := c.path()
+	if err != nil {
+		return "", err
+	}
+	args := []string{path}
+	switch name {
+	case "attribute_type", "begins_with", "contains":
+		if err := c.symbol(","); err != nil {
+			return "", err
+		}
+		arg, err := c.operand()
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+	}
+	if err := c.symbol(")"); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", ")), nil
+}
+
+// operand is an attribute path, a '?' argument, or size(path).
+func (c *conditionCompiler) operand() (string, error) {
+	t := c.peek()
+	switch {
+	case t.kind == tokenArg:
+		c.next()
+		if len(c.args) == 0 {
+			return "", fmt.Errorf("expression %q has more placeholders than arguments", c.expr)
+		}
+		v, err := c.ph.value(c.args[0])
+		if err != nil {
+			return "", fmt.Errorf("marshalling argument for expression %q: %w", c.expr, err)
+		}
+		c.args = c.args[1:]
+		return v, nil
+	case t.kind == tokenIdent && t.text == "size":
+		c.next()
+		if err := c.symbol("("); err != nil {
+			return "", err
+		}
+		p, err := c.path()
+		if err != nil {
+			return "", err
+		}
+		if err := c.symbol(")"); err != nil {
+			return "", err
+		}
+		return "size(" + p + ")", nil
+	}
+	return c.path()
+}
+
+func (c *conditionCompiler) path() (string, error) {
+	t := c.next()
+	if t.kind != tokenIdent || conditionFunctions[t.text] || isConditionKeyword(t.text) {
+		if t.kind == tokenEOF {
+			return "", c.errorf("unexpected end of expression")
+		}
+		return "", c.errorf("expected an attribute name, got %q", t.text)
+	}
+	p, err := c.ph.path(t.text)
+	if err != nil {
+		return "", c.errorf("%s", err)
+	}
+	return p, nil
+}
+
+func isConditionKeyword(s string) bool {
+	switch strings.ToUpper(s) {
+	case "AND", "OR", "NOT", "BETWEEN", "IN":
+		return true
+	}
+	return false
+}
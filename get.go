@@ -3,9 +3,7 @@ package ddb
 import (
 	"context"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 type GetOpts struct {
@@ -45,10 +43,7 @@ func (c *Client) Get(ctx context.Context, key GetKey, item Keyer, opts ...func(*
 	}
 
 	out, err := c.client.GetItem(ctx, &dynamodb.GetItemInput{
-		Key: map[string]types.AttributeValue{
-			"PK": &types.AttributeValueMemberS{Value: key.PK},
-			"SK": &types.AttributeValueMemberS{Value: key.SK},
-		},
+		Key:            c.schema.key(key),
 		TableName:      &c.table,
 		ConsistentRead: &gopts.ConsistentRead,
 	})
@@ -62,6 +57,6 @@ func (c *Client) Get(ctx context.Context, key GetKey, item Keyer, opts ...func(*
 		return res, ErrNoItems
 	}
 
-	err = attributevalue.UnmarshalMap(out.Item, item)
+	err = unmarshalItem(out.Item, item, c.schema)
 	return res, err
 }
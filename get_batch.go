@@ -0,0 +1,215 @@
+package ddb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// getBatchSize is the maximum number of keys which
+// can be requested in a single BatchGetItem call.
+const getBatchSize = 100
+
+type GetBatchResult struct {
+	// RawOutput contains the DynamoDB API responses, one per BatchGetItem call.
+	// Usually you won't need this, as results are parsed onto the out argument.
+	RawOutput []*dynamodb.BatchGetItemOutput
+
+	// Missing contains the keys which did not match an item.
+	Missing []GetKey
+}
+
+// MissingKeysError is returned by GetBatch if some of the keys did not match an item.
+// The items which were found are still written to the output.
+type MissingKeysError struct {
+	Keys []GetKey
+}
+
+func (e *MissingKeysError) Error() string {
+	return fmt.Sprintf("%d of the requested keys did not match an item", len(e.Keys))
+}
+
+// UnprocessedKeysError is returned by GetBatch if DynamoDB did not process
+// some of the keys after all retries were exhausted.
+// The items which were found are still written to the output.
+type UnprocessedKeysError struct {
+	Keys []GetKey
+}
+
+func (e *UnprocessedKeysError) Error() string {
+	return fmt.Sprintf("%d keys were not processed by DynamoDB after retrying", len(e.Keys))
+}
+
+// GetBatch calls BatchGetItem to get multiple items by their keys.
+// Like Get, GetBatch defaults to using consistent reads.
+//
+// The 'out' argument must be a pointer to a slice or a pointer to a map
+// keyed by GetKey:
+//
+//	var items []MyItem
+//	db.GetBatch(ctx, keys, &items)
+//
+//	var byKey map[ddb.GetKey]MyItem
+//	db.GetBatch(ctx, keys, &byKey)
+//
+// Slices are returned in the same order as the requested keys.
+// Keys which are requested more than once are only fetched once. For number
+// keys, different representations of the same number, such as "1" and "1.0",
+// are the same key.
+//
+// Keys are split into batches of 100, which is the BatchGetItem limit,
+// and unprocessed keys are retried with exponential backoff. If keys are
+// still unprocessed after retrying, the items which were found are written
+// to 'out' and an *UnprocessedKeysError is returned.
+//
+// If some keys did not match an item, the items which were found are
+// written to 'out' and a *MissingKeysError is returned. The missing keys
+// are also available in GetBatchResult.Missing.
+func (c *Client) GetBatch(ctx context.Context, keys []GetKey, out interface{}, opts ...func(*GetOpts)) (*GetBatchResult, error) {
+	gopts := GetOpts{
+		ConsistentRead: true,
+	}
+	for _, o := range opts {
+		o(&gopts)
+	}
+
+	outVal := reflect.ValueOf(out)
+	if outVal.Kind() != reflect.Pointer || outVal.IsNil() {
+		return nil, fmt.Errorf("GetBatch: out must be a non-nil pointer to a slice or map, got %T", out)
+	}
+	outVal = outVal.Elem()
+	switch outVal.Kind() {
+	case reflect.Slice:
+	case reflect.Map:
+		if outVal.Type().Key() != reflect.TypeOf(GetKey{}) {
+			return nil, fmt.Errorf("GetBatch: out map must be keyed by ddb.GetKey, got %T", out)
+		}
+	default:
+		return nil, fmt.Errorf("GetBatch: out must be a pointer to a slice or map, got %T", out)
+	}
+
+	// BatchGetItem returns an error if a key is requested more than once.
+	// Number keys are normalised, as "1" and "1.0" are the same key.
+	var unique []GetKey
+	requested := map[GetKey][]GetKey{}
+	for _, k := range keys {
+		nk := c.schema.normalizeKey(k)
+		if _, ok := requested[nk]; !ok {
+			unique = append(unique, nk)
+		}
+		if !containsKey(requested[nk], k) {
+			requested[nk] = append(requested[nk], k)
+		}
+	}
+
+	res := &GetBatchResult{}
+	found := map[GetKey]map[string]types.AttributeValue{}
+	unprocessed := map[GetKey]bool{}
+
+	for i := 0; i < len(unique); i += getBatchSize {
+		end := len(unique)
+		if i+getBatchSize < end {
+			end = i + getBatchSize
+		}
+		req := make([]map[string]types.AttributeValue, end-i)
+		for j, k := range unique[i:end] {
+			req[j] = c.schema.key(k)
+		}
+
+		for attempt := 0; len(req) > 0; attempt++ {
+			if attempt > 0 {
+				if attempt > c.maxBatchRetries {
+					for _, k := range c.schema.getKeys(req) {
+						unprocessed[k] = true
+					}
+					break
+				}
+				if err := sleep(ctx, backoff(attempt-1)); err != nil {
+					return res, err
+				}
+			}
+
+			batch, err := c.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: map[string]types.KeysAndAttributes{
+					c.table: {
+						Keys:           req,
+						ConsistentRead: &gopts.ConsistentRead,
+					},
+				},
+			})
+			if err != nil {
+				return res, err
+			}
+			res.RawOutput = append(res.RawOutput, batch)
+
+			for _, item := range batch.Responses[c.table] {
+				found[c.schema.normalizeKey(c.schema.getKey(item))] = item
+			}
+			req = batch.UnprocessedKeys[c.table].Keys
+		}
+	}
+
+	if outVal.Kind() == reflect.Map {
+		if outVal.IsNil() {
+			outVal.Set(reflect.MakeMap(outVal.Type()))
+		}
+	} else {
+		outVal.Set(reflect.MakeSlice(outVal.Type(), 0, len(found)))
+	}
+	elemType := outVal.Type().Elem()
+
+	var unprocessedKeys []GetKey
+	for _, k := range unique {
+		if unprocessed[k] {
+			unprocessedKeys = append(unprocessedKeys, requested[k]...)
+			continue
+		}
+		item, ok := found[k]
+		if !ok {
+			res.Missing = append(res.Missing, requested[k]...)
+			continue
+		}
+		elem := reflect.New(elemType)
+		err := unmarshalItem(item, elem.Interface(), c.schema)
+		if err != nil {
+			return res, err
+		}
+		if outVal.Kind() == reflect.Map {
+			for _, rk := range requested[k] {
+				outVal.SetMapIndex(reflect.ValueOf(rk), elem.Elem())
+			}
+		} else {
+			outVal.Set(reflect.Append(outVal, elem.Elem()))
+		}
+	}
+
+	if len(unprocessedKeys) > 0 {
+		return res, &UnprocessedKeysError{Keys: unprocessedKeys}
+	}
+	if len(res.Missing) > 0 {
+		return res, &MissingKeysError{Keys: res.Missing}
+	}
+	return res, nil
+}
+
+// containsKey returns true if keys contains k.
+func containsKey(keys []GetKey, k GetKey) bool {
+	for _, key := range keys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// getKeys returns the primary keys of DynamoDB items.
+func (s TableSchema) getKeys(items []map[string]types.AttributeValue) []GetKey {
+	keys := make([]GetKey, len(items))
+	for i, item := range items {
+		keys[i] = s.getKey(item)
+	}
+	return keys
+}
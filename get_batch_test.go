@@ -0,0 +1,188 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// batchGetDynamoDB returns the first key of each BatchGetItem
+// request as unprocessed, to test that unprocessed keys are retried.
+type batchGetDynamoDB struct {
+	DynamoDBAPI
+
+	missing  map[string]bool
+	requests []int
+}
+
+func (f *batchGetDynamoDB) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	keys := params.RequestItems["test-table"].Keys
+	f.requests = append(f.requests, len(keys))
+
+	out := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]types.AttributeValue{},
+	}
+	for i, k := range keys {
+		if i == 0 && len(keys) > 1 {
+			out.UnprocessedKeys = map[string]types.KeysAndAttributes{
+				"test-table": {Keys: []map[string]types.AttributeValue{k}},
+			}
+			continue
+		}
+		id := k["SK"].(*types.AttributeValueMemberS).Value
+		if !f.missing[id] {
+			out.Responses["test-table"] = append(out.Responses["test-table"], fakeThingItem(id))
+		}
+	}
+	return out, nil
+}
+
+func TestGetBatch(t *testing.T) {
+	f := &batchGetDynamoDB{missing: map[string]bool{"3": true}}
+	c, err := New(context.Background(), "test-table", WithDynamoDBClient(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []GetKey
+	for i := 0; i < 150; i++ {
+		keys = append(keys, GetKey{PK: "THING", SK: fmt.Sprint(i)})
+	}
+	// duplicate keys are only requested once.
+	keys = append(keys, keys[0])
+
+	var got []fakeThing
+	res, err := c.GetBatch(context.Background(), keys, &got)
+
+	var missing *MissingKeysError
+	assert.True(t, errors.As(err, &missing))
+	assert.Equal(t, []GetKey{{PK: "THING", SK: "3"}}, missing.Keys)
+	assert.Equal(t, missing.Keys, res.Missing)
+
+	// keys are split into batches of 100, and unprocessed keys are retried.
+	assert.Equal(t, []int{100, 1, 50, 1}, f.requests)
+
+	// results are in the same order as the keys.
+	assert.Len(t, got, 149)
+	assert.Equal(t, fakeThing{ID: "0"}, got[0])
+	assert.Equal(t, fakeThing{ID: "4"}, got[3])
+	assert.Equal(t, fakeThing{ID: "149"}, got[148])
+
+	var byKey map[GetKey]fakeThing
+	_, err = c.GetBatch(context.Background(), keys[:3], &byKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[GetKey]fakeThing{
+		{PK: "THING", SK: "0"}: {ID: "0"},
+		{PK: "THING", SK: "1"}: {ID: "1"},
+		{PK: "THING", SK: "2"}: {ID: "2"},
+	}, byKey)
+}
+
+func TestGetBatchUnprocessedKeys(t *testing.T) {
+	f := &batchGetDynamoDB{missing: map[string]bool{"2": true}}
+	c, err := New(context.Background(), "test-table", WithDynamoDBClient(f), WithMaxBatchRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []GetKey{{PK: "THING", SK: "0"}, {PK: "THING", SK: "1"}, {PK: "THING", SK: "2"}}
+	var got []fakeThing
+	res, err := c.GetBatch(context.Background(), keys, &got)
+
+	var unprocessed *UnprocessedKeysError
+	assert.True(t, errors.As(err, &unprocessed))
+	assert.Equal(t, []GetKey{{PK: "THING", SK: "0"}}, unprocessed.Keys)
+
+	// the items which were found and the missing keys are still returned.
+	assert.Equal(t, []fakeThing{{ID: "1"}}, got)
+	assert.Equal(t, []GetKey{{PK: "THING", SK: "2"}}, res.Missing)
+}
+
+// numberKeyDynamoDB returns an item for every key requested from a table
+// with a number sort key. Like DynamoDB, it returns numbers in their canonical form.
+type numberKeyDynamoDB struct {
+	DynamoDBAPI
+
+	requested []string
+}
+
+func (f *numberKeyDynamoDB) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	out := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]types.AttributeValue{},
+	}
+	for _, k := range params.RequestItems["test-table"].Keys {
+		sk := k["SK"].(*types.AttributeValueMemberN).Value
+		f.requested = append(f.requested, sk)
+		n, err := strconv.ParseFloat(sk, 64)
+		if err != nil {
+			return nil, err
+		}
+		id := strconv.FormatFloat(n, 'f', -1, 64)
+		out.Responses["test-table"] = append(out.Responses["test-table"], map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "THING"},
+			"SK": &types.AttributeValueMemberN{Value: id},
+			"ID": &types.AttributeValueMemberS{Value: id},
+		})
+	}
+	return out, nil
+}
+
+func TestGetBatchNumberKeys(t *testing.T) {
+	f := &numberKeyDynamoDB{}
+	schema := DefaultTableSchema()
+	schema.SortKeyType = types.ScalarAttributeTypeN
+	c, err := New(context.Background(), "test-table", WithDynamoDBClient(f), WithTableSchema(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []GetKey{{PK: "THING", SK: "01"}, {PK: "THING", SK: "1.0"}, {PK: "THING", SK: "2.50"}}
+	var got []fakeThing
+	_, err = c.GetBatch(context.Background(), keys, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "01" and "1.0" are the same key, so it is only requested once.
+	assert.Equal(t, []string{"1", "2.5"}, f.requested)
+	assert.Equal(t, []fakeThing{{ID: "1"}, {ID: "2.5"}}, got)
+
+	// maps are keyed by the requested keys.
+	var byKey map[GetKey]fakeThing
+	_, err = c.GetBatch(context.Background(), keys, &byKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[GetKey]fakeThing{
+		{PK: "THING", SK: "01"}:   {ID: "1"},
+		{PK: "THING", SK: "1.0"}:  {ID: "1"},
+		{PK: "THING", SK: "2.50"}: {ID: "2.5"},
+	}, byKey)
+}
+
+func TestGetBatchInvalidOutput(t *testing.T) {
+	c := newFakeClient(t, &fakeDynamoDB{})
+
+	tests := []struct {
+		name string
+		out  interface{}
+	}{
+		{name: "not a pointer", out: []fakeThing{}},
+		{name: "nil", out: nil},
+		{name: "struct", out: &fakeThing{}},
+		{name: "wrong map key", out: &map[string]fakeThing{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.GetBatch(context.Background(), []GetKey{{PK: "THING", SK: "1"}}, tt.out)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package ddb
+
+import (
+	"context"
+)
+
+// GetByIndex gets the single item in a global secondary index with the given keys.
+// It's intended for indexes holding a unique key, such as looking up a user by their email.
+// The index must be declared in the table schema. If sk is empty, only the partition
+// key is matched.
+//
+// ErrNoItems is returned if no items match, and a *MultipleItemsError is returned
+// if more than one item matches.
+//
+//	var user User
+//	err := db.GetByIndex(ctx, "GSI1", "EMAIL#"+email, "", &user)
+func (c *Client) GetByIndex(ctx context.Context, index string, pk string, sk string, item Keyer) error {
+	q := Q().Schema(c.schema).Index(index).PK(pk)
+	if sk != "" {
+		q.SK(sk)
+	}
+	// reading two items is enough to tell whether the key is unique.
+	return c.QueryOne(ctx, q, item, Limit(2))
+}
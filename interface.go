@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 // Storage defines a common interface to make testing ddb easier.
@@ -11,11 +12,31 @@ import (
 type Storage interface {
 	Query(ctx context.Context, qb QueryBuilder, opts ...func(*QueryOpts)) (*QueryResult, error)
 	All(ctx context.Context, qb QueryBuilder, opts ...func(*QueryOpts)) error
-	Put(ctx context.Context, item Keyer) error
+	// Iterate returns an iterator over the pages of a query.
+	// Each call to the iterator's Next() method unmarshals a page of results onto the QueryBuilder.
+	Iterate(ctx context.Context, qb QueryBuilder, opts ...func(*QueryOpts)) *QueryIterator
+	// Scan reads a page of items from the table using a ScanBuilder.
+	// Results are unmarshalled onto the ScanBuilder in the same way as Query.
+	Scan(ctx context.Context, sb ScanBuilder, opts ...func(*QueryOpts)) (*ScanResult, error)
+	// ScanAll reads every page of a scan, calling fn with the items in each page.
+	// Provide TotalSegments(n) to read the scan with n parallel workers.
+	ScanAll(ctx context.Context, sb ScanBuilder, fn func(items []map[string]types.AttributeValue) error, opts ...func(*QueryOpts)) error
+	// Put creates or updates an item.
+	// Options such as IfNotExists() or Condition() make the write conditional,
+	// in which case ErrConditionFailed is returned if the condition is not met.
+	Put(ctx context.Context, item Keyer, opts ...func(*WriteOpts)) error
 	PutBatch(ctx context.Context, items ...Keyer) error
-	TransactWriteItems(ctx context.Context, tx []TransactWriteItem) error
-	NewTransaction() Transaction
-	Delete(ctx context.Context, item Keyer) error
+	TransactWriteItems(ctx context.Context, tx []TransactWriteItem, opts ...func(*TransactionOpts)) error
+	NewTransaction(opts ...func(*TransactionOpts)) Transaction
+	// Delete deletes an item.
+	// Options such as IfExists() or Condition() make the delete conditional,
+	// in which case ErrConditionFailed is returned if the condition is not met.
+	Delete(ctx context.Context, item Keyer, opts ...func(*WriteOpts)) error
+	// Update makes a partial update to the item with the given key.
+	// The update is defined by an UpdateBuilder, such as ddb.NewUpdate().
+	//
+	//	db.Update(ctx, ddb.GetKey{PK: ..., SK: ...}, ddb.NewUpdate().Add("Count", 1))
+	Update(ctx context.Context, key GetKey, ub UpdateBuilder, opts ...func(*WriteOpts)) (*UpdateItemResult, error)
 	DeleteBatch(ctx context.Context, items ...Keyer) error
 	// Get performs a GetItem call to fetch a single item from DynamoDB.
 	// The results are written to the 'item' argument. This argument
@@ -24,9 +45,34 @@ type Storage interface {
 	// 	var item MyItem
 	//	db.Get(ctx, ddb.GetKey{PK: ..., SK: ...}, &item)
 	Get(ctx context.Context, key GetKey, item Keyer, opts ...func(*GetOpts)) (*GetItemResult, error)
+	// QueryOne queries for a single item, unmarshalling it onto 'item'.
+	// ErrNoItems is returned if no items match, and a *MultipleItemsError
+	// is returned if more than one item matches.
+	QueryOne(ctx context.Context, qb QueryBuilder, item Keyer, opts ...func(*QueryOpts)) error
+	// GetByIndex fetches the single item in a global secondary index with the given keys.
+	// ErrNoItems is returned if no items match, and an error matching
+	// ErrMultipleItems is returned if more than one item matches.
+	//
+	//	var user User
+	//	db.GetByIndex(ctx, "GSI1", "EMAIL#"+email, "", &user)
+	GetByIndex(ctx context.Context, index string, pk string, sk string, item Keyer) error
+	// GetBatch performs BatchGetItem calls to fetch multiple items from DynamoDB.
+	// The results are written to the 'out' argument, which must be a pointer
+	// to a slice or a pointer to a map keyed by GetKey.
+	//
+	//	var items []MyItem
+	//	db.GetBatch(ctx, []ddb.GetKey{{PK: ..., SK: ...}}, &items)
+	//
+	// If any keys did not match an item, a *MissingKeysError is returned.
+	GetBatch(ctx context.Context, keys []GetKey, out interface{}, opts ...func(*GetOpts)) (*GetBatchResult, error)
 	// Client returns the underlying DynamoDB client. It's useful for cases
 	// where you need more control over queries or writes than the ddb library provides.
+	//
+	// If the storage is not backed by a *dynamodb.Client, Client returns nil.
 	Client() *dynamodb.Client
+	// API returns the underlying DynamoDB API client. Unlike Client(), this
+	// also works when a custom DynamoDBAPI implementation has been provided.
+	API() DynamoDBAPI
 	// Table returns the name of the DynamoDB table that the client is configured to use.
 	Table() string
 }
@@ -34,15 +80,30 @@ type Storage interface {
 // Transactions allow atomic write operations to be made to a DynamoDB table.
 // DynamoDB transactions support up to 100 operations.
 //
-// Calling Put() and Delete() on a transaction register items in memory to be
+// Calling Put(), Delete(), Update() and ConditionCheck() on a transaction register items in memory to be
 // written to the table. No API calls are performed until Execute() is called.
+//
+// Each operation accepts options such as IfExists() or Condition(). If any condition
+// isn't met, the whole transaction is cancelled.
 type Transaction interface {
 	// Put adds an item to be written in the transaction.
-	Put(item Keyer)
+	Put(item Keyer, opts ...func(*WriteOpts))
 	// Delete adds a item to be delete in the transaction.
-	Delete(item Keyer)
-	// Execute the transaction.
-	// This calls the TransactWriteItems API.
+	Delete(item Keyer, opts ...func(*WriteOpts))
+	// Update adds a partial update to the item with the given key in the transaction.
+	Update(key GetKey, ub UpdateBuilder, opts ...func(*WriteOpts))
+	// ConditionCheck adds a condition on the item with the given key, without writing to it.
+	// A condition must be provided, for example IfExists() to require that a parent item exists:
+	//
+	//	tx.ConditionCheck(ddb.GetKey{PK: "ORG#1", SK: "ORG#1"}, ddb.IfExists())
+	ConditionCheck(key GetKey, opts ...func(*WriteOpts))
+	// Validate checks the transaction without calling the API. An error matching
+	// ErrInvalidTransaction is returned if the transaction is empty, has more than 100 operations,
+	// has more than one operation on the same item, or is larger than 4 MB.
+	Validate() error
+	// Execute the transaction. The transaction is validated before calling the API.
+	// This calls the TransactWriteItems API. If the transaction is cancelled, a *TransactionError
+	// is returned. Its operations are grouped by type, rather than in the order they were added.
 	// See: https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactWriteItems.html
 	Execute(ctx context.Context) error
 }
@@ -0,0 +1,71 @@
+package ddb
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// itemSize estimates the size of an item in bytes, using the rules DynamoDB
+// uses to calculate item sizes.
+//
+// see: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/CapacityUnitCalculations.html
+func itemSize(item map[string]types.AttributeValue) int {
+	var size int
+	for name, v := range item {
+		size += len(name) + attributeSize(v)
+	}
+	return size
+}
+
+func attributeSize(v types.AttributeValue) int {
+	switch v := v.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return numberSize(v.Value)
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberBOOL, *types.AttributeValueMemberNULL:
+		return 1
+	case *types.AttributeValueMemberSS:
+		var size int
+		for _, s := range v.Value {
+			size += len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		var size int
+		for _, n := range v.Value {
+			size += numberSize(n)
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		var size int
+		for _, b := range v.Value {
+			size += len(b)
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		// lists and maps have 3 bytes of overhead, plus 1 byte for each element.
+		size := 3
+		for _, e := range v.Value {
+			size += attributeSize(e) + 1
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		size := 3
+		for name, e := range v.Value {
+			size += len(name) + attributeSize(e) + 1
+		}
+		return size
+	}
+	return 0
+}
+
+// numberSize estimates the size of a number, which DynamoDB stores
+// using 1 byte for every 2 significant digits, plus 1 byte.
+func numberSize(n string) int {
+	digits := strings.Trim(strings.NewReplacer("-", "", "+", "", ".", "").Replace(n), "0")
+	return (len(digits)+1)/2 + 1
+}
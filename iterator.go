@@ -0,0 +1,104 @@
+package ddb
+
+import (
+	"context"
+	"reflect"
+)
+
+// QueryIterator reads the pages of a query one at a time.
+// Unlike All, only the current page is held in memory, and
+// iteration can be stopped at any point.
+//
+// Each call to Next runs the query for the next page, calling BuildQuery
+// again and unmarshalling the page's results onto the QueryBuilder in the
+// same way as Query.
+//
+//	q := &ListApples{}
+//	it := db.Iterate(ctx, q)
+//	for it.Next(ctx) {
+//		// q.Result contains the items in the current page.
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type QueryIterator struct {
+	s    Storage
+	qb   QueryBuilder
+	opts []func(*QueryOpts)
+
+	res     *QueryResult
+	started bool
+	done    bool
+	err     error
+}
+
+// NewQueryIterator creates an iterator over the pages of a query, using any Storage
+// implementation to run the query. Usually you'll call Iterate() on a Client instead.
+func NewQueryIterator(s Storage, qb QueryBuilder, opts ...func(*QueryOpts)) *QueryIterator {
+	return &QueryIterator{s: s, qb: qb, opts: opts}
+}
+
+// Iterate returns an iterator over the pages of a query.
+// Options such as Limit() and Page() are applied to the first page.
+// The query isn't run until Next() is called.
+func (c *Client) Iterate(ctx context.Context, qb QueryBuilder, opts ...func(*QueryOpts)) *QueryIterator {
+	return NewQueryIterator(c, qb, opts...)
+}
+
+// Next fetches the next page of results, unmarshalling them onto the QueryBuilder.
+// It returns false when there are no more pages, or if an error occurred.
+// Check Err() after Next returns false.
+//
+// Pages can be empty if the query uses a filter expression.
+func (it *QueryIterator) Next(ctx context.Context) bool {
+	if it.done || it.err != nil {
+		return false
+	}
+
+	opts := it.opts
+	if it.started {
+		opts = append(append([]func(*QueryOpts){}, it.opts...), Page(it.res.NextPage))
+	}
+
+	// reset the results field so that each page is unmarshalled to a new slice,
+	// rather than reusing the previous page's slice. This allows callers to
+	// retain the results of earlier pages.
+	if _, ok := it.qb.(QueryOutputUnmarshaler); !ok {
+		if rt, _ := findResultsTag(it.qb); rt != nil {
+			rt.Elem().Set(reflect.Zero(rt.Elem().Type()))
+		}
+	}
+
+	res, err := it.s.Query(ctx, it.qb, opts...)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.started = true
+	it.res = res
+	if res == nil || res.NextPage == "" {
+		it.done = true
+	}
+	return true
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *QueryIterator) Err() error {
+	return it.err
+}
+
+// Result returns the result of the current page.
+// It returns nil if Next() hasn't been called.
+func (it *QueryIterator) Result() *QueryResult {
+	return it.res
+}
+
+// NextPage returns the page token for the page following the current page.
+// It's empty if the current page is the last page.
+// The token can be passed to Page() to resume the query later.
+func (it *QueryIterator) NextPage() string {
+	if it.res == nil {
+		return ""
+	}
+	return it.res.NextPage
+}
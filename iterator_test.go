@@ -0,0 +1,92 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingQuery records how many times BuildQuery is called.
+type countingQuery struct {
+	builds int
+	err    error
+	Result []fakeThing `ddb:"result"`
+}
+
+func (q *countingQuery) BuildQuery() (*dynamodb.QueryInput, error) {
+	q.builds++
+	return &dynamodb.QueryInput{}, q.err
+}
+
+func fakeQueryPages(ids ...string) []*dynamodb.QueryOutput {
+	pages := make([]*dynamodb.QueryOutput, len(ids))
+	for i, id := range ids {
+		pages[i] = &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{fakeThingItem(id)}}
+		if i < len(ids)-1 {
+			pages[i].LastEvaluatedKey = map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "THING"}, "SK": &types.AttributeValueMemberS{Value: id}}
+		}
+	}
+	return pages
+}
+
+func TestQueryIterator(t *testing.T) {
+	f := &fakeDynamoDB{pages: fakeQueryPages("1", "2", "3")}
+	c := newFakeClient(t, f)
+
+	q := &countingQuery{}
+	it := c.Iterate(context.Background(), q, Limit(1))
+
+	var got [][]fakeThing
+	var tokens []string
+	for it.Next(context.Background()) {
+		got = append(got, q.Result)
+		tokens = append(tokens, it.NextPage())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, [][]fakeThing{{{ID: "1"}}, {{ID: "2"}}, {{ID: "3"}}}, got)
+	assert.Equal(t, 3, q.builds)
+	assert.NotEmpty(t, tokens[0])
+	assert.Empty(t, tokens[2])
+	assert.Equal(t, "2", f.queries[2].ExclusiveStartKey["SK"].(*types.AttributeValueMemberS).Value)
+	for _, in := range f.queries {
+		assert.Equal(t, int32(1), *in.Limit)
+	}
+
+	// calling Next after the last page returns false.
+	assert.False(t, it.Next(context.Background()))
+}
+
+func TestQueryIteratorStop(t *testing.T) {
+	f := &fakeDynamoDB{pages: fakeQueryPages("1", "2", "3")}
+	c := newFakeClient(t, f)
+
+	it := c.Iterate(context.Background(), &countingQuery{})
+	assert.True(t, it.Next(context.Background()))
+
+	// stopping early doesn't fetch the remaining pages,
+	// and the query can be resumed from the next page token.
+	assert.Len(t, f.queries, 1)
+
+	q := &countingQuery{}
+	it = c.Iterate(context.Background(), q, Page(it.NextPage()))
+	assert.True(t, it.Next(context.Background()))
+	assert.Equal(t, []fakeThing{{ID: "2"}}, q.Result)
+	assert.Equal(t, "1", f.queries[1].ExclusiveStartKey["SK"].(*types.AttributeValueMemberS).Value)
+}
+
+func TestQueryIteratorError(t *testing.T) {
+	c := newFakeClient(t, &fakeDynamoDB{})
+
+	wantErr := errors.New("build failed")
+	it := c.Iterate(context.Background(), &countingQuery{err: wantErr})
+	assert.False(t, it.Next(context.Background()))
+	assert.Equal(t, wantErr, it.Err())
+	assert.Nil(t, it.Result())
+}
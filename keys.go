@@ -2,7 +2,8 @@ package ddb
 
 // Keys are primary and Global Secondary Index (GSI)
 // keys to be used when storing an item in DynamoDB.
-// The ddb package is opinionated on the naming of these keys.
+// By default, the keys are stored in attributes with the same names as the fields.
+// Use WithTableSchema() to store them in attributes with different names.
 type Keys struct {
 	PK     string
 	SK     string
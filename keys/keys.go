@@ -0,0 +1,268 @@
+// Package keys builds and parses composite keys for single-table designs,
+// such as 'ORG#123#USER#456'.
+//
+// A key is made up of segments joined by a delimiter. Delimiters and
+// backslashes inside segments are escaped with a backslash, so any string
+// can be used as a segment and parsed back out of the key:
+//
+//	k := keys.New("ORG", orgID, "USER", userID)
+//	k.String() // "ORG#123#USER#456"
+//
+//	parsed, err := keys.Parse("ORG#123#USER#456")
+//	userID, ok := parsed.Value("USER") // "456", true
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultDelimiter is the delimiter used by New() and Parse().
+const DefaultDelimiter = '#'
+
+const escape = '\\'
+
+// Format is a key encoding using a particular delimiter.
+// The zero value uses DefaultDelimiter.
+type Format struct {
+	delimiter rune
+}
+
+// NewFormat returns a Format which separates segments with delimiter.
+// The delimiter can't be a backslash, as it is used for escaping.
+func NewFormat(delimiter rune) (Format, error) {
+	if delimiter == escape || delimiter == utf8.RuneError || delimiter == 0 {
+		return Format{}, fmt.Errorf("invalid key delimiter %q", delimiter)
+	}
+	return Format{delimiter: delimiter}, nil
+}
+
+// Delimiter returns the delimiter which separates key segments.
+func (f Format) Delimiter() rune {
+	if f.delimiter == 0 {
+		return DefaultDelimiter
+	}
+	return f.delimiter
+}
+
+// New returns a key with the given segments.
+func (f Format) New(segments ...string) Key {
+	return Key{format: f, segments: append([]string(nil), segments...)}
+}
+
+// ErrInvalidKey is returned when a key can't be parsed.
+var ErrInvalidKey = errors.New("invalid key")
+
+// Parse splits a key into its segments, unescaping them.
+func (f Format) Parse(s string) (Key, error) {
+	k := Key{format: f}
+	d := f.Delimiter()
+	var seg strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if r != d && r != escape {
+				return Key{}, fmt.Errorf("%w: %q has an unknown escape sequence", ErrInvalidKey, s)
+			}
+			seg.WriteRune(r)
+			escaped = false
+		case r == escape:
+			escaped = true
+		case r == d:
+			k.segments = append(k.segments, seg.String())
+			seg.Reset()
+		default:
+			seg.WriteRune(r)
+		}
+	}
+	if escaped {
+		return Key{}, fmt.Errorf("%w: %q ends with an escape character", ErrInvalidKey, s)
+	}
+	k.segments = append(k.segments, seg.String())
+	return k, nil
+}
+
+// New returns a key with the given segments, using DefaultDelimiter.
+func New(segments ...string) Key {
+	return Format{}.New(segments...)
+}
+
+// Parse splits a key using DefaultDelimiter into its segments.
+func Parse(s string) (Key, error) {
+	return Format{}.Parse(s)
+}
+
+// Key is a composite key made up of segments.
+// Keys are immutable: methods which add segments return a new Key.
+type Key struct {
+	format   Format
+	segments []string
+}
+
+// Add returns a copy of the key with the segments appended.
+func (k Key) Add(segments ...string) Key {
+	out := make([]string, 0, len(k.segments)+len(segments))
+	out = append(out, k.segments...)
+	return Key{format: k.format, segments: append(out, segments...)}
+}
+
+// Uint returns a copy of the key with n appended as a segment,
+// zero-padded to width digits so that numbers sort correctly.
+// Numbers with more than width digits are not truncated, so width
+// should be large enough for the largest expected value.
+func (k Key) Uint(n uint64, width int) Key {
+	return k.Add(fmt.Sprintf("%0*d", width, n))
+}
+
+// TimeFormat is the layout used by Time(). Unlike time.RFC3339Nano,
+// it has a fixed width, so keys containing times sort chronologically.
+const TimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// Time returns a copy of the key with t appended as a segment.
+// The time is converted to UTC and formatted with TimeFormat.
+func (k Key) Time(t time.Time) Key {
+	return k.Add(t.UTC().Format(TimeFormat))
+}
+
+// Segments returns the unescaped segments of the key.
+func (k Key) Segments() []string {
+	return append([]string(nil), k.segments...)
+}
+
+// Len returns the number of segments in the key.
+func (k Key) Len() int {
+	return len(k.segments)
+}
+
+// Segment returns the segment at index i, or an empty string if the key
+// doesn't have that many segments.
+func (k Key) Segment(i int) string {
+	if i < 0 || i >= len(k.segments) {
+		return ""
+	}
+	return k.segments[i]
+}
+
+// Value returns the segment following the first segment equal to label.
+// For example, Value("USER") returns "456" for the key 'ORG#123#USER#456'.
+func (k Key) Value(label string) (string, bool) {
+	for i := 0; i < len(k.segments)-1; i++ {
+		if k.segments[i] == label {
+			return k.segments[i+1], true
+		}
+	}
+	return "", false
+}
+
+// UintValue parses the segment following label as an unsigned integer.
+func (k Key) UintValue(label string) (uint64, error) {
+	v, ok := k.Value(label)
+	if !ok {
+		return 0, fmt.Errorf("key %q does not contain %q", k.String(), label)
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
+
+// TimeValue parses the segment following label as a time formatted with TimeFormat.
+func (k Key) TimeValue(label string) (time.Time, error) {
+	v, ok := k.Value(label)
+	if !ok {
+		return time.Time{}, fmt.Errorf("key %q does not contain %q", k.String(), label)
+	}
+	return time.Parse(TimeFormat, v)
+}
+
+// HasPrefix reports whether the key begins with the segments of prefix.
+func (k Key) HasPrefix(prefix Key) bool {
+	if len(prefix.segments) > len(k.segments) {
+		return false
+	}
+	for i, s := range prefix.segments {
+		if k.segments[i] != s {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the key with its segments escaped and joined by the delimiter.
+func (k Key) String() string {
+	d := k.format.Delimiter()
+	var b strings.Builder
+	for i, s := range k.segments {
+		if i > 0 {
+			b.WriteRune(d)
+		}
+		for _, r := range s {
+			if r == d || r == escape {
+				b.WriteRune(escape)
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Prefix returns the key followed by a delimiter. It matches keys which
+// have all the segments of k followed by at least one more segment,
+// so 'USER#' matches 'USER#1' but not 'USERS#1'.
+func (k Key) Prefix() string {
+	return k.String() + string(k.format.Delimiter())
+}
+
+// Condition is a key condition expression which can be used
+// in the QueryInput returned by a QueryBuilder.
+type Condition struct {
+	Expression string
+	Names      map[string]string
+	Values     map[string]types.AttributeValue
+}
+
+// BeginsWith returns a key condition which matches items in the partition pk
+// whose sort key begins with prefix. Use Key.Prefix() to match keys which
+// have more segments than a key:
+//
+//	cond := keys.BeginsWith("PK", keys.New("ORG", orgID).String(), "SK", keys.New("USER").Prefix())
+func BeginsWith(pkAttr, pk, skAttr, prefix string) Condition {
+	return Condition{
+		Expression: "#pk = :pk AND begins_with(#sk, :sk)",
+		Names: map[string]string{
+			"#pk": pkAttr,
+			"#sk": skAttr,
+		},
+		Values: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: pk},
+			":sk": &types.AttributeValueMemberS{Value: prefix},
+		},
+	}
+}
+
+// QueryInput returns a QueryInput using the condition as its KeyConditionExpression.
+//
+//	func (l *ListOrgUsers) BuildQuery() (*dynamodb.QueryInput, error) {
+//		cond := keys.BeginsWith("PK", keys.New("ORG", l.OrgID).String(), "SK", keys.New("USER").Prefix())
+//		return cond.QueryInput(), nil
+//	}
+func (c Condition) QueryInput() *dynamodb.QueryInput {
+	names := make(map[string]string, len(c.Names))
+	for k, v := range c.Names {
+		names[k] = v
+	}
+	values := make(map[string]types.AttributeValue, len(c.Values))
+	for k, v := range c.Values {
+		values[k] = v
+	}
+	return &dynamodb.QueryInput{
+		KeyConditionExpression:    &c.Expression,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+}
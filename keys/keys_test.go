@@ -0,0 +1,173 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/common-fate/ddb"
+	"github.com/common-fate/ddb/ddbmem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyString(t *testing.T) {
+	pipe, err := NewFormat('|')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		give Key
+		want string
+	}{
+		{
+			name: "segments",
+			give: New("ORG", "123", "USER", "456"),
+			want: "ORG#123#USER#456",
+		},
+		{
+			name: "add",
+			give: New("ORG", "123").Add("USER", "456"),
+			want: "ORG#123#USER#456",
+		},
+		{
+			name: "escaping",
+			give: New("NAME", `a#b\c`),
+			want: `NAME#a\#b\\c`,
+		},
+		{
+			name: "zero padded",
+			give: New("INVOICE").Uint(42, 6),
+			want: "INVOICE#000042",
+		},
+		{
+			name: "time",
+			give: New("AT").Time(time.Date(2023, 1, 2, 3, 4, 5, 600, time.FixedZone("", 3600))),
+			want: "AT#2023-01-02T02:04:05.000000600Z",
+		},
+		{
+			name: "custom delimiter",
+			give: pipe.New("ORG", "a#b|c"),
+			want: `ORG|a#b\|c`,
+		},
+		{
+			name: "empty segment",
+			give: New("ORG", ""),
+			want: "ORG#",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.give.String())
+
+			// keys always parse back to their original segments.
+			got, err := tt.give.format.Parse(tt.give.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.give.Segments(), got.Segments())
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	k, err := Parse("ORG#123#USER#456#AT#2023-01-02T03:04:05.000000000Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 6, k.Len())
+	assert.Equal(t, "ORG", k.Segment(0))
+	assert.Equal(t, "", k.Segment(6))
+
+	user, ok := k.Value("USER")
+	assert.True(t, ok)
+	assert.Equal(t, "456", user)
+
+	n, err := k.UintValue("ORG")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(123), n)
+
+	at, err := k.TimeValue("AT")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), at)
+
+	_, ok = k.Value("TEAM")
+	assert.False(t, ok)
+	_, err = k.UintValue("TEAM")
+	assert.Error(t, err)
+
+	assert.True(t, k.HasPrefix(New("ORG", "123")))
+	assert.False(t, k.HasPrefix(New("ORG", "12")))
+
+	_, err = Parse(`ORG\`)
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+	_, err = Parse(`ORG\x`)
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+
+	_, err = NewFormat('\\')
+	assert.Error(t, err)
+}
+
+func TestUintSorts(t *testing.T) {
+	want := []string{New("N").Uint(2, 4).String(), New("N").Uint(10, 4).String(), New("N").Uint(100, 4).String()}
+	got := []string{want[2], want[0], want[1]}
+	sort.Strings(got)
+	assert.Equal(t, want, got)
+}
+
+func TestPrefix(t *testing.T) {
+	assert.Equal(t, "ORG#1#", New("ORG", "1").Prefix())
+	assert.Equal(t, `A\##`, New("A#").Prefix())
+}
+
+type member struct {
+	PK   string
+	SK   string
+	Name string
+}
+
+func (m member) DDBKeys() (ddb.Keys, error) {
+	return ddb.Keys{PK: m.PK, SK: m.SK}, nil
+}
+
+type listOrgUsers struct {
+	OrgID  string
+	Result []member `ddb:"result"`
+}
+
+func (l *listOrgUsers) BuildQuery() (*dynamodb.QueryInput, error) {
+	cond := BeginsWith("PK", New("ORG", l.OrgID).String(), "SK", New("USER").Prefix())
+	return cond.QueryInput(), nil
+}
+
+func TestBeginsWith(t *testing.T) {
+	ctx := context.Background()
+	db, err := ddbmem.New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	org := New("ORG", "1").String()
+	items := []ddb.Keyer{
+		member{PK: org, SK: New("USER", "a").String(), Name: "a"},
+		member{PK: org, SK: New("USER", "b").String(), Name: "b"},
+		member{PK: org, SK: New("USERS", "c").String(), Name: "c"},
+		member{PK: org, SK: New("TEAM", "d").String(), Name: "d"},
+		member{PK: New("ORG", "2").String(), SK: New("USER", "e").String(), Name: "e"},
+	}
+	err = db.PutBatch(ctx, items...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := &listOrgUsers{OrgID: "1"}
+	_, err = db.Query(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []member{items[0].(member), items[1].(member)}, q.Result)
+}
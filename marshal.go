@@ -1,15 +1,16 @@
 package ddb
 
 import (
-	"reflect"
-
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 // marshalItem turns an item into it's DynamoDB representation.
-func marshalItem(item Keyer) (map[string]types.AttributeValue, error) {
-	keys, err := item.DDBKeys()
+// Key attributes are named according to the table schema.
+// If the item doesn't implement Keyer, its keys are derived from `ddb` struct tags.
+func marshalItem(item interface{}, schema TableSchema) (map[string]types.AttributeValue, error) {
+	item = Unwrap(item)
+	keys, err := itemKeys(item)
 	if err != nil {
 		return nil, err
 	}
@@ -20,22 +21,23 @@ func marshalItem(item Keyer) (map[string]types.AttributeValue, error) {
 		return nil, err
 	}
 
-	v := reflect.ValueOf(keys)
-	// add the keys to the object
-	for i := 0; i < v.NumField(); i++ {
-		k := v.Type().Field(i).Name
-		val := v.Field(i).String()
-
-		// any fields which are empty strings are useless to write to DynamoDB.
-		// when iterating through the object we ignore these.
-		if val != "" {
-			objAttrs[k] = &types.AttributeValueMemberS{Value: val}
-		}
+	// add the keys to the object.
+	// any keys which are empty strings are useless to write to DynamoDB,
+	// so they are omitted.
+	keyAttrs, err := schema.keyAttributes(keys)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range keyAttrs {
+		objAttrs[k] = v
 	}
 
-	// if the object implements EntityTyper, add a 'ddb:type' field with its type.
+	// if the object implements EntityTyper, or its type has been registered with Register,
+	// add a 'ddb:type' field with its type.
 	if et, ok := item.(EntityTyper); ok {
 		objAttrs["ddb:type"] = &types.AttributeValueMemberS{Value: et.EntityType()}
+	} else if name, ok := registeredEntityType(item); ok {
+		objAttrs["ddb:type"] = &types.AttributeValueMemberS{Value: name}
 	}
 
 	return objAttrs, nil
@@ -40,7 +40,7 @@ func Test_marshalItem(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := marshalItem(tt.give)
+			got, err := marshalItem(tt.give, DefaultTableSchema())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("marshalItem() error = %v, wantErr %v", err, tt.wantErr)
 				return
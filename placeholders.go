@@ -0,0 +1,142 @@
+package ddb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// placeholders generates placeholder names and values for
+// DynamoDB expressions, such as '#u0' and ':u0'.
+//
+// Using placeholders for every attribute name means that expressions
+// never conflict with DynamoDB reserved words.
+type placeholders struct {
+	// prefix is used to prevent placeholders from different
+	// expressions conflicting with one another.
+	prefix string
+	names  map[string]string
+	values map[string]types.AttributeValue
+	// byName allows placeholders to be reused if the same
+	// attribute name is referenced more than once.
+	byName map[string]string
+}
+
+func newPlaceholders(prefix string) *placeholders {
+	return &placeholders{
+		prefix: prefix,
+		names:  map[string]string{},
+		values: map[string]types.AttributeValue{},
+		byName: map[string]string{},
+	}
+}
+
+// clone returns a copy of the placeholders.
+func (p *placeholders) clone() *placeholders {
+	c := newPlaceholders(p.prefix)
+	for k, v := range p.names {
+		c.names[k] = v
+	}
+	for k, v := range p.values {
+		c.values[k] = v
+	}
+	for k, v := range p.byName {
+		c.byName[k] = v
+	}
+	return c
+}
+
+// name returns the placeholder for a single attribute name.
+func (p *placeholders) name(attr string) string {
+	if ph, ok := p.byName[attr]; ok {
+		return ph
+	}
+	ph := fmt.Sprintf("#%s%d", p.prefix, len(p.names))
+	p.names[ph] = attr
+	p.byName[attr] = ph
+	return ph
+}
+
+// path returns the placeholder for a document path.
+// Nested attributes are separated by dots, and list elements
+// are referenced with square brackets, for example 'Address.Lines[0]'.
+func (p *placeholders) path(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("attribute path must not be empty")
+	}
+	elements := strings.Split(path, ".")
+	for i, el := range elements {
+		name := el
+		index := ""
+		if j := strings.Index(el, "["); j != -1 {
+			name, index = el[:j], el[j:]
+			if !validIndexes(index) {
+				return "", fmt.Errorf("invalid list index in attribute path %q", path)
+			}
+		}
+		if name == "" {
+			return "", fmt.Errorf("invalid attribute path %q", path)
+		}
+		elements[i] = p.name(name) + index
+	}
+	return strings.Join(elements, "."), nil
+}
+
+// validIndexes reports whether s is a sequence of list indexes such as '[0][1]'.
+func validIndexes(s string) bool {
+	for s != "" {
+		end := strings.Index(s, "]")
+		if s[0] != '[' || end < 2 {
+			return false
+		}
+		for _, r := range s[1:end] {
+			if r < '0' || r > '9' {
+				return false
+			}
+		}
+		s = s[end+1:]
+	}
+	return true
+}
+
+// value returns the placeholder for a value, marshalling it
+// to a DynamoDB attribute value.
+func (p *placeholders) value(v interface{}) (string, error) {
+	av, ok := v.(types.AttributeValue)
+	if !ok {
+		var err error
+		av, err = attributevalue.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+	}
+	ph := fmt.Sprintf(":%s%d", p.prefix, len(p.values))
+	p.values[ph] = av
+	return ph, nil
+}
+
+// mergeExpressionAttributes merges the placeholder names and values from src into dst.
+// An error is returned if a placeholder is defined differently in both.
+func mergeExpressionAttributes(dstNames *map[string]string, dstValues *map[string]types.AttributeValue, names map[string]string, values map[string]types.AttributeValue) error {
+	for k, v := range names {
+		if *dstNames == nil {
+			*dstNames = map[string]string{}
+		}
+		if existing, ok := (*dstNames)[k]; ok && existing != v {
+			return fmt.Errorf("expression attribute name %s is defined more than once", k)
+		}
+		(*dstNames)[k] = v
+	}
+	for k, v := range values {
+		if *dstValues == nil {
+			*dstValues = map[string]types.AttributeValue{}
+		}
+		if _, ok := (*dstValues)[k]; ok {
+			return fmt.Errorf("expression attribute value %s is defined more than once", k)
+		}
+		(*dstValues)[k] = v
+	}
+	return nil
+}
@@ -8,17 +8,60 @@ import (
 )
 
 // Put calls PutItem to create or update an item in DynamoDB.
-func (c *Client) Put(ctx context.Context, item Keyer) error {
-	attrs, err := marshalItem(item)
+//
+// Put can be made conditional by providing options such as IfNotExists() or Condition().
+// If the condition is not met, ErrConditionFailed is returned.
+//
+// If the item is Versioned, the put only succeeds if the stored version matches
+// the item's version, and ErrVersionConflict is returned otherwise. If the write also
+// has other conditions, ErrConditionFailed is returned instead, as DynamoDB doesn't
+// report which of the conditions failed.
+//
+// To read the item which was replaced by the put, provide ReturnOld(&item).
+func (c *Client) Put(ctx context.Context, item Keyer, opts ...func(*WriteOpts)) error {
+	wo := WriteOpts{}
+	for _, o := range opts {
+		o(&wo)
+	}
+
+	if err := wo.checkOldReturnValues("Put"); err != nil {
+		return err
+	}
+
+	attrs, err := marshalItem(item, c.schema)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.client.PutItem(ctx, &dynamodb.PutItemInput{
-		Item:      attrs,
-		TableName: &c.table,
+	condition := wo.buildCondition(c.schema)
+
+	version, err := versionOf(item)
+	if err != nil {
+		return err
+	}
+	if version != nil {
+		attrs[version.attr] = version.next()
+		condition = condition.and(version.condition())
+	}
+
+	expr, names, values := conditionArgs(condition)
+
+	out, err := c.client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:                      attrs,
+		TableName:                 &c.table,
+		ConditionExpression:       expr,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              wo.ReturnValues,
 	})
-	return err
+	if err != nil {
+		return conditionError(err, version, wo)
+	}
+
+	if version != nil {
+		version.update()
+	}
+	return wo.unmarshalReturnValues(out.Attributes, c.schema)
 }
 
 // PutBatch calls BatchWriteItem to create or update items in DynamoDB.
@@ -27,10 +70,22 @@ func (c *Client) Put(ctx context.Context, item Keyer) error {
 // PutBatch will automatically split the items into batches of 25 by default.
 //
 // You can override this default batch size using WithBatchSize(n) when you initialize the client.
+// To write batches in parallel, use WithBatchConcurrency(n).
+//
+// Items which DynamoDB doesn't process, for example due to throttling, are retried
+// with exponential backoff. If items are still unprocessed after retrying,
+// a *BatchWriteError listing them is returned. A *BatchWriteError is also returned
+// if any batches fail, listing the batches and their errors.
+//
+// BatchWriteItem doesn't support conditions, so PutBatch returns an error
+// without writing anything if any of the items are Versioned.
 func (c *Client) PutBatch(ctx context.Context, items ...Keyer) error {
+	if err := checkNotVersioned("PutBatch", items); err != nil {
+		return err
+	}
 	wr := make([]types.WriteRequest, len(items))
 	for i, item := range items {
-		dbItem, err := marshalItem(item)
+		dbItem, err := marshalItem(item, c.schema)
 		if err != nil {
 			return err
 		}
@@ -40,19 +95,5 @@ func (c *Client) PutBatch(ctx context.Context, items ...Keyer) error {
 			},
 		}
 	}
-	for i := 0; i < len(wr); i += c.batchSize {
-		end := len(wr)
-		if i+c.batchSize < end {
-			end = i + c.batchSize
-		}
-		_, err := c.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]types.WriteRequest{
-				c.table: wr[i:end],
-			},
-		})
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return c.batchWrite(ctx, wr, items)
 }
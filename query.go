@@ -4,9 +4,10 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/pkg/errors"
 )
 
@@ -23,6 +24,19 @@ type QueryOpts struct {
 	PageToken      string
 	Limit          int32
 	ConsistentRead bool
+	// Segment and TotalSegments are used to split a Scan into segments
+	// which can be read in parallel. Queries return an error if they are set.
+	Segment       *int32
+	TotalSegments int32
+	// MaxItems and MaxPages limit how much All() reads.
+	// They are ignored by Query, and Scan returns an error if they are set.
+	MaxItems int
+	MaxPages int
+	// Reverse, Projection and Count change how the query is read.
+	// Scan returns an error if they are set.
+	Reverse    bool
+	Projection []string
+	Count      *int
 }
 
 // QueryOutputUnmarshalers implement custom logic to
@@ -56,11 +70,43 @@ func ConsistentRead() func(*QueryOpts) {
 	}
 }
 
+// Reverse reverses the order that the query reads items in.
+// Queries which read items in ascending sort key order, which is the default,
+// read them in descending order instead. This allows an access pattern to be
+// reused to read the newest items first.
+func Reverse() func(*QueryOpts) {
+	return func(qo *QueryOpts) {
+		qo.Reverse = true
+	}
+}
+
+// Project reads only the given attributes of each item, rather than the entire item.
+// It is mapped to the 'ProjectionExpression' argument in the dynamodb.Query method.
+// Attribute paths are written in the same way as for NewUpdate(), and may be reserved words.
+func Project(fields ...string) func(*QueryOpts) {
+	return func(qo *QueryOpts) {
+		qo.Projection = append(qo.Projection, fields...)
+	}
+}
+
+// Count counts the items matching the query, rather than reading them.
+// The count is written to total, and items are not unmarshalled onto the
+// QueryBuilder. With Query(), the count is of a single page, and is also
+// returned in QueryResult.Count. With All(), total is the count across all pages.
+func Count(total *int) func(*QueryOpts) {
+	return func(qo *QueryOpts) {
+		qo.Count = total
+	}
+}
+
 type QueryResult struct {
 	// RawOutput is the DynamoDB API response. Usually you won't need this,
 	// as results are parsed onto the QueryBuilder argument.
 	RawOutput *dynamodb.QueryOutput
 
+	// Count is the number of items in the page which matched the query.
+	Count int
+
 	// NextPage is the next page token. If empty, there is no next page.
 	NextPage string
 }
@@ -81,19 +127,51 @@ type QueryResult struct {
 // The examples in this package show how to write simple and complex access patterns
 // which use each of the three methods above.
 func (c *Client) Query(ctx context.Context, qb QueryBuilder, opts ...func(*QueryOpts)) (*QueryResult, error) {
-	q, err := qb.BuildQuery()
+	qo := QueryOpts{}
+	for _, o := range opts {
+		o(&qo)
+	}
+
+	result, err := c.queryPage(ctx, qb, qo)
 	if err != nil {
 		return nil, err
 	}
 
-	qo := QueryOpts{}
-	for _, o := range opts {
-		o(&qo)
+	if qo.Count != nil {
+		*qo.Count = result.Count
+		return result, nil
+	}
+
+	// call the custom unmarshalling logic if the QueryBuilder implements it.
+	if rp, ok := qb.(QueryOutputUnmarshaler); ok {
+		err = rp.UnmarshalQueryOutput(result.RawOutput)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	err = unmarshalItems(result.RawOutput.Items, qb, c.schema)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// queryPage calls the Query API for a single page, without unmarshalling the results.
+func (c *Client) queryPage(ctx context.Context, qb QueryBuilder, qo QueryOpts) (*QueryResult, error) {
+	if qo.Segment != nil || qo.TotalSegments != 0 {
+		return nil, errors.New("Segment() and TotalSegments() can only be used with Scan and ScanAll")
+	}
+
+	q, err := qb.BuildQuery()
+	if err != nil {
+		return nil, err
 	}
 
 	// ensure that we have a tokenizer so that we don't nil panic
 	if c.tokenizer == nil {
-		return nil, errors.New("a page encoder must be set up to use pagination (call ddb.WithPageEncoder when setting up the client to fix)")
+		return nil, errors.New("a page encoder must be set up to use pagination (call ddb.WithPageTokenizer when setting up the client to fix)")
 	}
 
 	// set up query pagination if it's provided
@@ -113,6 +191,35 @@ func (c *Client) Query(ctx context.Context, qb QueryBuilder, opts ...func(*Query
 	// set strong consistency if it's enabled
 	q.ConsistentRead = &qo.ConsistentRead
 
+	if qo.Reverse {
+		forward := q.ScanIndexForward != nil && !*q.ScanIndexForward
+		q.ScanIndexForward = &forward
+	}
+
+	if len(qo.Projection) > 0 {
+		if qo.Count != nil {
+			return nil, errors.New("Project() can't be used with Count()")
+		}
+		ph := newPlaceholders("p")
+		paths := make([]string, len(qo.Projection))
+		for i, f := range qo.Projection {
+			paths[i], err = ph.path(f)
+			if err != nil {
+				return nil, err
+			}
+		}
+		projection := strings.Join(paths, ", ")
+		q.ProjectionExpression = &projection
+		err = mergeExpressionAttributes(&q.ExpressionAttributeNames, &q.ExpressionAttributeValues, ph.names, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if qo.Count != nil {
+		q.Select = types.SelectCount
+	}
+
 	// query builders don't necessarily know which table the client uses,
 	// so update the query input to override the table name.
 	q.TableName = &c.table
@@ -124,6 +231,7 @@ func (c *Client) Query(ctx context.Context, qb QueryBuilder, opts ...func(*Query
 
 	result := &QueryResult{
 		RawOutput: got,
+		Count:     int(got.Count),
 	}
 
 	// marshal the LastEvaluatedKey into a pagination token if pagination is enabled.
@@ -134,56 +242,66 @@ func (c *Client) Query(ctx context.Context, qb QueryBuilder, opts ...func(*Query
 		}
 		result.NextPage = s
 	}
+	return result, nil
+}
 
-	// call the custom unmarshalling logic if the QueryBuilder implements it.
-	if rp, ok := qb.(QueryOutputUnmarshaler); ok {
-		err = rp.UnmarshalQueryOutput(got)
-		if err != nil {
-			return nil, err
-		}
-		return result, nil
-	}
-
-	var out interface{} = qb
+// unmarshalItems unmarshals items onto the field of 'out' with a
+// `ddb:"result"` struct tag. If there is no such field, the items
+// are unmarshalled directly to 'out'.
+func unmarshalItems(items []map[string]types.AttributeValue, out interface{}, schema TableSchema) error {
+	var target interface{} = out
 
-	// check if the QueryBuilder contains a 'ddb:"result"' struct tag
-	resultTag, err := findResultsTag(qb)
+	// check if the output contains a 'ddb:"result"' struct tag
+	resultTag, opts, err := findResultsField(out)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if resultTag != nil {
-		out = resultTag.Interface()
+		target = resultTag.Interface()
 	}
 
-	// Otherwise, default to the unmarshalling logic provided by the attributevalue package.
-	err = attributevalue.UnmarshalListOfMaps(got.Items, out)
-	if err != nil {
-		return nil, err
+	// items of mixed entity types are unmarshalled using the entity registry.
+	if e, ok := target.(*Entities); ok {
+		return unmarshalEntities(items, e, opts == "skipunknown", schema)
 	}
-	return result, nil
+
+	// Otherwise, default to the unmarshalling logic provided by the attributevalue package.
+	return unmarshalList(items, target, schema)
 }
 
 // findResultsTag returns the first struct field with a `ddb:"result"` tag.
 func findResultsTag(out interface{}) (*reflect.Value, error) {
-	v := reflect.ValueOf(out).Elem()
+	v, _, err := findResultsField(out)
+	return v, err
+}
+
+// findResultsField returns the first struct field with a `ddb:"result"` tag,
+// along with any options following the tag name, such as `ddb:"result,skipunknown"`.
+func findResultsField(out interface{}) (*reflect.Value, string, error) {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		// results can only be assigned through a pointer.
+		return nil, "", nil
+	}
+	v := rv.Elem()
 
 	if v.Kind() != reflect.Struct {
 		// we can't parse this
-		return nil, nil
+		return nil, "", nil
 	}
 
 	if !v.CanAddr() {
-		return nil, fmt.Errorf("cannot assign to the item passed, item must be a pointer in order to assign")
+		return nil, "", fmt.Errorf("cannot assign to the item passed, item must be a pointer in order to assign")
 	}
 
 	for i := 0; i < v.NumField(); i++ {
 		f := v.Type().Field(i)
 		tag, ok := f.Tag.Lookup("ddb")
-		if ok && tag == "result" {
-			// return nil, nil
+		name, opts, _ := strings.Cut(tag, ",")
+		if ok && name == "result" {
 			addr := reflect.Indirect(v).Field(i).Addr()
-			return &addr, nil
+			return &addr, opts, nil
 		}
 	}
-	return nil, nil
+	return nil, "", nil
 }
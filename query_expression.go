@@ -0,0 +1,217 @@
+package ddb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// QueryExpression builds a QueryInput from key conditions and filters.
+// Placeholders are generated for all attribute names and values,
+// so reserved words can be used as attribute names. Key attribute names
+// are taken from the table schema, which defaults to DefaultTableSchema().
+//
+// QueryExpression is intended to be used inside a QueryBuilder:
+//
+//	func (l *ListActiveUsers) BuildQuery() (*dynamodb.QueryInput, error) {
+//		return ddb.Q().
+//			Index("GSI1").
+//			PK("ORG#" + l.OrgID).
+//			SKBeginsWith("USER#").
+//			Filter("Status = ? AND size(Roles) > ?", "ACTIVE", 0).
+//			BuildQuery()
+//	}
+//
+// Errors, such as an invalid filter expression or an unknown index,
+// are returned by BuildQuery().
+type QueryExpression struct {
+	schema TableSchema
+	index  string
+	pk     interface{}
+	hasPK  bool
+	// sk is the format of the sort key condition, and skValues are its arguments.
+	sk       string
+	skValues []interface{}
+	filters  []string
+	ph       *placeholders
+	// err is the first error encountered while building the query.
+	err error
+}
+
+var _ QueryBuilder = &QueryExpression{}
+
+// Q creates a new QueryExpression.
+func Q() *QueryExpression {
+	return &QueryExpression{
+		schema: DefaultTableSchema(),
+		ph:     newPlaceholders("q"),
+	}
+}
+
+// Schema sets the table schema used to look up key attribute names.
+// It should match the schema passed to WithTableSchema().
+func (q *QueryExpression) Schema(s TableSchema) *QueryExpression {
+	q.schema = s
+	return q
+}
+
+// Index queries a global secondary index declared in the table schema,
+// rather than the table itself.
+func (q *QueryExpression) Index(name string) *QueryExpression {
+	q.index = name
+	return q
+}
+
+// PK sets the partition key value to query.
+func (q *QueryExpression) PK(value interface{}) *QueryExpression {
+	q.pk = value
+	q.hasPK = true
+	return q
+}
+
+// SK matches items with a sort key equal to value.
+func (q *QueryExpression) SK(value interface{}) *QueryExpression {
+	return q.skCondition("%s = %s", value)
+}
+
+// SKBeginsWith matches items with a sort key beginning with prefix.
+func (q *QueryExpression) SKBeginsWith(prefix string) *QueryExpression {
+	return q.skCondition("begins_with(%s, %s)", prefix)
+}
+
+// SKBetween matches items with a sort key between low and high, inclusive.
+func (q *QueryExpression) SKBetween(low, high interface{}) *QueryExpression {
+	return q.skCondition("%s BETWEEN %s AND %s", low, high)
+}
+
+// SKLessThan matches items with a sort key less than value.
+func (q *QueryExpression) SKLessThan(value interface{}) *QueryExpression {
+	return q.skCondition("%s < %s", value)
+}
+
+// SKLessThanOrEqual matches items with a sort key less than or equal to value.
+func (q *QueryExpression) SKLessThanOrEqual(value interface{}) *QueryExpression {
+	return q.skCondition("%s <= %s", value)
+}
+
+// SKGreaterThan matches items with a sort key greater than value.
+func (q *QueryExpression) SKGreaterThan(value interface{}) *QueryExpression {
+	return q.skCondition("%s > %s", value)
+}
+
+// SKGreaterThanOrEqual matches items with a sort key greater than or equal to value.
+func (q *QueryExpression) SKGreaterThanOrEqual(value interface{}) *QueryExpression {
+	return q.skCondition("%s >= %s", value)
+}
+
+// skCondition records a sort key condition. The values are only turned into
+// placeholders by BuildQuery(), once the sort key type is known.
+func (q *QueryExpression) skCondition(format string, values ...interface{}) *QueryExpression {
+	if q.sk != "" {
+		q.setErr(errors.New("query can only have one sort key condition"))
+		return q
+	}
+	q.sk = format
+	q.skValues = values
+	return q
+}
+
+// Filter adds a filter expression, which is applied to items after they are read.
+// Filters added by multiple calls must all match.
+//
+// Attribute names are written as-is, and values are passed as arguments
+// in place of '?' characters:
+//
+//	Filter("Status IN (?, ?) AND attribute_exists(DeletedAt)", "ACTIVE", "PENDING")
+//
+// The expression supports comparisons, BETWEEN, IN, AND, OR, NOT, parentheses,
+// size() and the attribute_exists, attribute_not_exists, attribute_type,
+// begins_with and contains functions.
+func (q *QueryExpression) Filter(expr string, args ...interface{}) *QueryExpression {
+	f, err := compileCondition(expr, args, q.ph)
+	if err != nil {
+		q.setErr(err)
+		return q
+	}
+	q.filters = append(q.filters, f)
+	return q
+}
+
+func (q *QueryExpression) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// BuildQuery builds the Query input.
+// An error is returned if the partition key isn't set, if the index isn't
+// declared in the table schema, or if any of the conditions were invalid.
+func (q *QueryExpression) BuildQuery() (*dynamodb.QueryInput, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if !q.hasPK {
+		return nil, errors.New("query must have a partition key")
+	}
+
+	pkAttr, skAttr := q.schema.PartitionKey, q.schema.SortKey
+	pkType, skType := q.schema.PartitionKeyType, q.schema.SortKeyType
+	in := &dynamodb.QueryInput{}
+	if q.index != "" {
+		idx, ok := q.schema.Index(q.index)
+		if !ok {
+			return nil, fmt.Errorf("index %s is not declared in the table schema", q.index)
+		}
+		pkAttr, skAttr = idx.PartitionKey, idx.SortKey
+		pkType, skType = idx.PartitionKeyType, idx.SortKeyType
+		in.IndexName = &idx.Name
+	}
+
+	// build the placeholders into a copy, so that BuildQuery can be called more than once.
+	ph := q.ph.clone()
+	pk, err := ph.value(keyArg(pkType, q.pk))
+	if err != nil {
+		return nil, fmt.Errorf("marshalling partition key: %w", err)
+	}
+	conds := []string{ph.name(pkAttr) + " = " + pk}
+
+	if q.sk != "" {
+		if skAttr == "" {
+			return nil, errors.New("query has a sort key condition, but the table schema has no sort key")
+		}
+		args := []interface{}{ph.name(skAttr)}
+		for _, v := range q.skValues {
+			s, err := ph.value(keyArg(skType, v))
+			if err != nil {
+				return nil, fmt.Errorf("marshalling sort key: %w", err)
+			}
+			args = append(args, s)
+		}
+		conds = append(conds, fmt.Sprintf(q.sk, args...))
+	}
+
+	keyCond := strings.Join(conds, " AND ")
+	in.KeyConditionExpression = &keyCond
+	if len(q.filters) > 0 {
+		filter := q.filters[0]
+		if len(q.filters) > 1 {
+			filter = "(" + strings.Join(q.filters, ") AND (") + ")"
+		}
+		in.FilterExpression = &filter
+	}
+	in.ExpressionAttributeNames = ph.names
+	in.ExpressionAttributeValues = ph.values
+	return in, nil
+}
+
+// keyArg converts string key values to the key type declared in the schema,
+// in the same way as the fields of GetKey.
+func keyArg(t types.ScalarAttributeType, v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return keyValue(t, s)
+	}
+	return v
+}
@@ -0,0 +1,212 @@
+package ddb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		give    *QueryExpression
+		want    *dynamodb.QueryInput
+		wantErr bool
+	}{
+		{
+			name: "partition key",
+			give: Q().PK("USER#1"),
+			want: &dynamodb.QueryInput{
+				KeyConditionExpression:    aws.String("#q0 = :q0"),
+				ExpressionAttributeNames:  map[string]string{"#q0": "PK"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{":q0": &types.AttributeValueMemberS{Value: "USER#1"}},
+			},
+		},
+		{
+			name: "begins with on index",
+			give: Q().Index("GSI1").PK("ORG#1").SKBeginsWith("USER#"),
+			want: &dynamodb.QueryInput{
+				IndexName:                aws.String("GSI1"),
+				KeyConditionExpression:   aws.String("#q0 = :q0 AND begins_with(#q1, :q1)"),
+				ExpressionAttributeNames: map[string]string{"#q0": "GSI1PK", "#q1": "GSI1SK"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":q0": &types.AttributeValueMemberS{Value: "ORG#1"},
+					":q1": &types.AttributeValueMemberS{Value: "USER#"},
+				},
+			},
+		},
+		{
+			name: "between with filters",
+			give: Q().PK("A").SKBetween("1", "2").Filter("Status = ?", "ACTIVE").Filter("attribute_not_exists(Size)"),
+			want: &dynamodb.QueryInput{
+				KeyConditionExpression: aws.String("#q2 = :q1 AND #q3 BETWEEN :q2 AND :q3"),
+				FilterExpression:       aws.String("(#q0 = :q0) AND (attribute_not_exists(#q1))"),
+				ExpressionAttributeNames: map[string]string{
+					"#q0": "Status", "#q1": "Size", "#q2": "PK", "#q3": "SK",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":q0": &types.AttributeValueMemberS{Value: "ACTIVE"},
+					":q1": &types.AttributeValueMemberS{Value: "A"},
+					":q2": &types.AttributeValueMemberS{Value: "1"},
+					":q3": &types.AttributeValueMemberS{Value: "2"},
+				},
+			},
+		},
+		{
+			name: "number sort key",
+			give: Q().Schema(TableSchema{PartitionKey: "id", SortKey: "ts", SortKeyType: types.ScalarAttributeTypeN}).PK("a").SKGreaterThan("100"),
+			want: &dynamodb.QueryInput{
+				KeyConditionExpression:   aws.String("#q0 = :q0 AND #q1 > :q1"),
+				ExpressionAttributeNames: map[string]string{"#q0": "id", "#q1": "ts"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":q0": &types.AttributeValueMemberS{Value: "a"},
+					":q1": &types.AttributeValueMemberN{Value: "100"},
+				},
+			},
+		},
+		{
+			name:    "missing partition key",
+			give:    Q().SK("1"),
+			wantErr: true,
+		},
+		{
+			name:    "two sort key conditions",
+			give:    Q().PK("A").SK("1").SKLessThan("2"),
+			wantErr: true,
+		},
+		{
+			name:    "unknown index",
+			give:    Q().Index("GSI9").PK("A"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid filter",
+			give:    Q().PK("A").Filter("Status = "),
+			wantErr: true,
+		},
+		{
+			name:    "value placeholder in filter",
+			give:    Q().PK("A").Filter("Status = :s"),
+			wantErr: true,
+		},
+		{
+			name:    "no sort key in schema",
+			give:    Q().Schema(TableSchema{PartitionKey: "id"}).PK("A").SK("1"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.give.BuildQuery()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+
+			// building the query again gives the same result.
+			if err == nil {
+				again, err := tt.give.BuildQuery()
+				assert.NoError(t, err)
+				assert.Equal(t, got, again)
+			}
+		})
+	}
+}
+
+func TestCompileCondition(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		args    []interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "reserved words",
+			expr: "Size >= ? AND Name <> ?",
+			args: []interface{}{1, "x"},
+			want: "#c0 >= :c0 AND #c1 <> :c1",
+		},
+		{
+			name: "functions and nesting",
+			expr: "NOT (begins_with(Name, ?) OR contains(Tags, ?)) AND size(Tags) < ?",
+			args: []interface{}{"a", "b", 3},
+			want: "NOT (begins_with(#c0, :c0) OR contains(#c1, :c1)) AND size(#c1) < :c2",
+		},
+		{
+			name: "in and paths",
+			expr: "Address.Lines[0] IN (?, ?) and attribute_type(Score, ?)",
+			args: []interface{}{"a", "b", "N"},
+			want: "#c0.#c1[0] IN (:c0, :c1) AND attribute_type(#c2, :c2)",
+		},
+		{
+			name:    "too few args",
+			expr:    "A = ? AND B = ?",
+			args:    []interface{}{1},
+			wantErr: true,
+		},
+		{
+			name:    "too many args",
+			expr:    "A = ?",
+			args:    []interface{}{1, 2},
+			wantErr: true,
+		},
+		{
+			name:    "manual placeholders",
+			expr:    "#a = :a",
+			wantErr: true,
+		},
+		{
+			name:    "value placeholder",
+			expr:    "A = :a",
+			wantErr: true,
+		},
+		{
+			name:    "name placeholder",
+			expr:    "#a = ?",
+			args:    []interface{}{1},
+			wantErr: true,
+		},
+		{
+			name: "colon within name",
+			expr: "ddb:version = ?",
+			args: []interface{}{1},
+			want: "#c0 = :c0",
+		},
+		{
+			name:    "unbalanced parentheses",
+			expr:    "(A = ?",
+			args:    []interface{}{1},
+			wantErr: true,
+		},
+		{
+			name:    "missing comparison",
+			expr:    "A ?",
+			args:    []interface{}{1},
+			wantErr: true,
+		},
+		{
+			name:    "unknown function",
+			expr:    "exists(A)",
+			wantErr: true,
+		},
+		{
+			name:    "keyword as attribute",
+			expr:    "AND = ?",
+			args:    []interface{}{1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compileCondition(tt.expr, tt.args, newPlaceholders("c"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileCondition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
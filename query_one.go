@@ -0,0 +1,74 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MultipleItemsError is returned by QueryOne when more than one item matches the query.
+// It matches ErrMultipleItems when used with errors.Is.
+type MultipleItemsError struct {
+	// Count is the number of matching items read before QueryOne stopped reading pages.
+	// It is at least 2, but may be less than the total number of matching items.
+	Count int
+}
+
+func (e *MultipleItemsError) Error() string {
+	return fmt.Sprintf("%s: %d items matched", ErrMultipleItems, e.Count)
+}
+
+func (e *MultipleItemsError) Is(target error) bool {
+	return target == ErrMultipleItems
+}
+
+// errStopPaging is returned by the allPages callback in QueryOne to stop reading pages.
+var errStopPaging = errors.New("stop paging")
+
+// QueryOne queries for a single item, unmarshalling it onto 'item'.
+// The item must be passed by reference.
+//
+// Pages are read until a second matching item is found, so that items matched by a
+// FilterExpression are found even if they aren't in the first page. ErrNoItems is returned
+// if no items match, and a *MultipleItemsError is returned if more than one item matches.
+// The Count() option isn't supported, as it doesn't read items.
+//
+//	var user User
+//	err := db.QueryOne(ctx, ddb.Q().Index("GSI1").PK("EMAIL#"+email), &user)
+func (c *Client) QueryOne(ctx context.Context, qb QueryBuilder, item Keyer, opts ...func(*QueryOpts)) error {
+	qo := QueryOpts{}
+	for _, o := range opts {
+		o(&qo)
+	}
+	if qo.Count != nil {
+		return errors.New("Count() can't be used with QueryOne, as no items are read when counting: use Query or All instead")
+	}
+
+	var first map[string]types.AttributeValue
+	count := 0
+	err := c.allPages(ctx, qb, qo, func(out *dynamodb.QueryOutput) error {
+		if first == nil && len(out.Items) > 0 {
+			first = out.Items[0]
+		}
+		count += len(out.Items)
+		if count > 1 {
+			// the query isn't unique, so there's no need to read any more pages.
+			return errStopPaging
+		}
+		return nil
+	})
+	if err != nil && err != errStopPaging {
+		return err
+	}
+
+	switch {
+	case count == 0:
+		return ErrNoItems
+	case count > 1:
+		return &MultipleItemsError{Count: count}
+	}
+	return unmarshalItem(first, item, c.schema)
+}
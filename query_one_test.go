@@ -0,0 +1,48 @@
+package ddb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryOneStopsPaging(t *testing.T) {
+	f := &fakeDynamoDB{pages: fakeQueryPages("1", "2", "3", "4")}
+	c := newFakeClient(t, f)
+
+	var got fakeThing
+	err := c.QueryOne(context.Background(), Q().PK("THING"), &got, Limit(1))
+	var multi *MultipleItemsError
+	if assert.ErrorAs(t, err, &multi) {
+		assert.Equal(t, 2, multi.Count)
+	}
+	// pages stop being read once a second item is found.
+	assert.Len(t, f.queries, 2)
+}
+
+func TestQueryOneCount(t *testing.T) {
+	f := &fakeDynamoDB{pages: []*dynamodb.QueryOutput{{Count: 1}}}
+	c := newFakeClient(t, f)
+
+	var n int
+	var got fakeThing
+	err := c.QueryOne(context.Background(), Q().PK("THING"), &got, Count(&n))
+	assert.EqualError(t, err, "Count() can't be used with QueryOne, as no items are read when counting: use Query or All instead")
+	assert.Empty(t, f.queries)
+}
+
+func TestGetByIndexReadsTwoItems(t *testing.T) {
+	f := &fakeDynamoDB{pages: []*dynamodb.QueryOutput{{Items: fakeQueryPages("1")[0].Items}}}
+	c := newFakeClient(t, f)
+
+	var got fakeThing
+	err := c.GetByIndex(context.Background(), "GSI1", "THING", "", &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fakeThing{ID: "1"}, got)
+	assert.Len(t, f.queries, 1)
+	assert.Equal(t, int32(2), *f.queries[0].Limit)
+}
@@ -1,8 +1,12 @@
 package ddb
 
 import (
+	"context"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,3 +49,110 @@ func TestFindResultsTag(t *testing.T) {
 	}
 
 }
+
+func TestQueryOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		give    *dynamodb.QueryInput
+		opts    []func(*QueryOpts)
+		want    *dynamodb.QueryInput
+		wantErr bool
+	}{
+		{
+			name: "reverse",
+			give: &dynamodb.QueryInput{},
+			opts: []func(*QueryOpts){Reverse()},
+			want: &dynamodb.QueryInput{ScanIndexForward: aws.Bool(false)},
+		},
+		{
+			name: "reverse descending query",
+			give: &dynamodb.QueryInput{ScanIndexForward: aws.Bool(false)},
+			opts: []func(*QueryOpts){Reverse()},
+			want: &dynamodb.QueryInput{ScanIndexForward: aws.Bool(true)},
+		},
+		{
+			name: "project",
+			give: &dynamodb.QueryInput{ExpressionAttributeNames: map[string]string{"#pk": "PK"}},
+			opts: []func(*QueryOpts){Project("ID", "Size", "Address.City")},
+			want: &dynamodb.QueryInput{
+				ProjectionExpression:     aws.String("#p0, #p1, #p2.#p3"),
+				ExpressionAttributeNames: map[string]string{"#pk": "PK", "#p0": "ID", "#p1": "Size", "#p2": "Address", "#p3": "City"},
+			},
+		},
+		{
+			name: "count",
+			give: &dynamodb.QueryInput{},
+			opts: []func(*QueryOpts){Count(new(int))},
+			want: &dynamodb.QueryInput{Select: types.SelectCount},
+		},
+		{
+			name:    "project and count",
+			give:    &dynamodb.QueryInput{},
+			opts:    []func(*QueryOpts){Project("ID"), Count(new(int))},
+			wantErr: true,
+		},
+		{
+			name:    "segment",
+			give:    &dynamodb.QueryInput{},
+			opts:    []func(*QueryOpts){Segment(0, 2)},
+			wantErr: true,
+		},
+		{
+			name:    "total segments",
+			give:    &dynamodb.QueryInput{},
+			opts:    []func(*QueryOpts){TotalSegments(2)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeDynamoDB{pages: []*dynamodb.QueryOutput{{Count: 2}}}
+			c := &Client{client: f, table: "test", tokenizer: &JSONTokenizer{}, schema: DefaultTableSchema()}
+
+			_, err := c.queryPage(context.Background(), &staticQuery{in: tt.give}, applyQueryOpts(tt.opts))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("queryPage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := f.queries[0]
+			got.TableName, got.ConsistentRead = nil, nil
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQueryCount(t *testing.T) {
+	f := &fakeDynamoDB{pages: []*dynamodb.QueryOutput{{Count: 2, Items: []map[string]types.AttributeValue{fakeThingItem("1")}}}}
+	c := &Client{client: f, table: "test", tokenizer: &JSONTokenizer{}, schema: DefaultTableSchema()}
+
+	var n int
+	q := &countingQuery{}
+	res, err := c.Query(context.Background(), q, Count(&n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, res.Count)
+	// items are not unmarshalled in count mode.
+	assert.Nil(t, q.Result)
+}
+
+// staticQuery returns a copy of a QueryInput.
+type staticQuery struct {
+	in *dynamodb.QueryInput
+}
+
+func (q *staticQuery) BuildQuery() (*dynamodb.QueryInput, error) {
+	in := *q.in
+	return &in, nil
+}
+
+func applyQueryOpts(opts []func(*QueryOpts)) QueryOpts {
+	var qo QueryOpts
+	for _, o := range opts {
+		o(&qo)
+	}
+	return qo
+}
@@ -0,0 +1,128 @@
+package ddb
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// registry maps entity type names to Go types.
+var registry = struct {
+	mu     sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{
+	byName: map[string]reflect.Type{},
+	byType: map[reflect.Type]string{},
+}
+
+// Register registers T as the Go type of items with the given entity type,
+// so that they can be read into an Entities result field.
+// Items of type T are written with a 'ddb:type' attribute containing the entity
+// type, in the same way as items which implement EntityTyper.
+//
+// Register is intended to be called from an init function or at startup:
+//
+//	func init() {
+//		ddb.Register[Invoice]("invoice")
+//		ddb.Register[LineItem]("lineItem")
+//	}
+//
+// Register panics if the entity type is empty, or if either the entity type
+// or T has already been registered differently.
+func Register[T any](entityType string) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if entityType == "" {
+		panic(fmt.Sprintf("ddb: entity type for %s must not be empty", t))
+	}
+	if t.Kind() == reflect.Pointer {
+		panic(fmt.Sprintf("ddb: register the element type of %s rather than a pointer", t))
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if existing, ok := registry.byName[entityType]; ok && existing != t {
+		panic(fmt.Sprintf("ddb: entity type %q is already registered to %s", entityType, existing))
+	}
+	if existing, ok := registry.byType[t]; ok && existing != entityType {
+		panic(fmt.Sprintf("ddb: %s is already registered as entity type %q", t, existing))
+	}
+	registry.byName[entityType] = t
+	registry.byType[t] = entityType
+}
+
+// registeredEntityType returns the entity type registered for the type of item.
+func registeredEntityType(item interface{}) (string, bool) {
+	t := reflect.TypeOf(item)
+	if t == nil {
+		return "", false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	name, ok := registry.byType[t]
+	return name, ok
+}
+
+// Entities is a result field type for queries which return items of mixed entity types,
+// such as an invoice and its line items. Each item is unmarshalled into the Go type
+// registered for its 'ddb:type' attribute with Register, and holds a value of that type.
+//
+//	type GetInvoice struct {
+//		ID     string
+//		Result ddb.Entities `ddb:"result"`
+//	}
+//
+//	for _, e := range q.Result {
+//		switch v := e.(type) {
+//		case Invoice:
+//		case LineItem:
+//		}
+//	}
+//
+// By default, items with an unregistered entity type cause an error wrapping
+// ErrUnknownEntityType to be returned. Tag the field with `ddb:"result,skipunknown"`
+// to skip these items instead.
+type Entities []interface{}
+
+// EntitiesOf returns the entities of type T.
+func EntitiesOf[T any](e Entities) []T {
+	var out []T
+	for _, v := range e {
+		if t, ok := v.(T); ok {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// unmarshalEntities unmarshals items into the Go types registered for their entity types.
+func unmarshalEntities(items []map[string]types.AttributeValue, out *Entities, skipUnknown bool, schema TableSchema) error {
+	*out = make(Entities, 0, len(items))
+	for _, item := range items {
+		name, _ := GetItemEntityType(item)
+
+		registry.mu.RLock()
+		t, ok := registry.byName[name]
+		registry.mu.RUnlock()
+
+		if !ok {
+			if skipUnknown {
+				continue
+			}
+			return fmt.Errorf("%w: %q", ErrUnknownEntityType, name)
+		}
+
+		v := reflect.New(t)
+		err := unmarshalItem(item, v.Interface(), schema)
+		if err != nil {
+			return err
+		}
+		*out = append(*out, v.Elem().Interface())
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package ddb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type registryInvoice struct {
+	ID    string   `ddb:"pk,template=INVOICE#{ID}" dynamodbav:"-"`
+	_     struct{} `ddb:"sk,template=INVOICE"`
+	Total int
+}
+
+type registryLineItem struct {
+	Invoice string
+	Line    int
+}
+
+func (l registryLineItem) DDBKeys() (Keys, error) {
+	return Keys{PK: "INVOICE#" + l.Invoice, SK: "LINE"}, nil
+}
+
+func init() {
+	Register[registryInvoice]("registryInvoice")
+	Register[registryLineItem]("registryLineItem")
+}
+
+type getRegistryInvoice struct {
+	Result Entities `ddb:"result"`
+}
+
+type getRegistryInvoiceSkipUnknown struct {
+	Result Entities `ddb:"result,skipunknown"`
+}
+
+func TestRegister(t *testing.T) {
+	// registering the same type again is allowed.
+	Register[registryInvoice]("registryInvoice")
+
+	assert.Panics(t, func() { Register[registryInvoice]("other") })
+	assert.Panics(t, func() { Register[registryLineItem]("registryInvoice") })
+	assert.Panics(t, func() { Register[*registryLineItem]("pointer") })
+	assert.Panics(t, func() { Register[registryLineItem]("") })
+}
+
+func TestUnmarshalEntities(t *testing.T) {
+	invoice, err := marshalItem(registryInvoice{ID: "1", Total: 10}, DefaultTableSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "registryInvoice"}, invoice["ddb:type"])
+
+	line, err := marshalItem(&registryLineItem{Invoice: "1", Line: 2}, DefaultTableSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "registryLineItem"}, line["ddb:type"])
+
+	unknown := map[string]types.AttributeValue{"ddb:type": &types.AttributeValueMemberS{Value: "unknown"}}
+	untyped := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "1"}}
+
+	var q getRegistryInvoice
+	err = unmarshalItems([]map[string]types.AttributeValue{invoice, line}, &q, DefaultTableSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, Entities{registryInvoice{ID: "1", Total: 10}, registryLineItem{Invoice: "1", Line: 2}}, q.Result)
+	assert.Equal(t, []registryLineItem{{Invoice: "1", Line: 2}}, EntitiesOf[registryLineItem](q.Result))
+
+	err = unmarshalItems([]map[string]types.AttributeValue{invoice, unknown}, &q, DefaultTableSchema())
+	assert.True(t, errors.Is(err, ErrUnknownEntityType))
+
+	var skip getRegistryInvoiceSkipUnknown
+	err = unmarshalItems([]map[string]types.AttributeValue{unknown, invoice, untyped}, &skip, DefaultTableSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, Entities{registryInvoice{ID: "1", Total: 10}}, skip.Result)
+}
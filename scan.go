@@ -0,0 +1,254 @@
+package ddb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+)
+
+// ScanBuilders build scan inputs for reading every item in a table or index.
+// The inputs are passed to the Scan DynamoDB API.
+//
+// Scans read the entire table, so they are expensive on large tables.
+// Prefer a QueryBuilder for access patterns in application code.
+type ScanBuilder interface {
+	BuildScan() (*dynamodb.ScanInput, error)
+}
+
+// ScanOutputUnmarshalers implement custom logic to
+// unmarshal the results of a DynamoDB Scan call.
+type ScanOutputUnmarshaler interface {
+	UnmarshalScanOutput(out *dynamodb.ScanOutput) error
+}
+
+// Segment reads a single segment of a Scan, out of totalSegments.
+// Segments allow a scan to be split across multiple workers.
+// https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Scan.html#Scan.ParallelScan
+func Segment(segment, totalSegments int32) func(*QueryOpts) {
+	return func(qo *QueryOpts) {
+		qo.Segment = &segment
+		qo.TotalSegments = totalSegments
+	}
+}
+
+// TotalSegments causes ScanAll to split the scan into segments,
+// each of which is read by a separate worker in parallel.
+func TotalSegments(totalSegments int32) func(*QueryOpts) {
+	return func(qo *QueryOpts) {
+		qo.TotalSegments = totalSegments
+	}
+}
+
+type ScanResult struct {
+	// RawOutput is the DynamoDB API response. Usually you won't need this,
+	// as results are parsed onto the ScanBuilder argument.
+	RawOutput *dynamodb.ScanOutput
+
+	// NextPage is the next page token. If empty, there is no next page.
+	NextPage string
+}
+
+// Scan reads a page of items from DynamoDB using a given ScanBuilder.
+// Under the hood, this uses the Scan API.
+//
+// Results are unmarshalled in the same way as Query:
+//
+// 1. If sb implements UnmarshalScanOutput, call it and return.
+//
+// 2. If sb contains a field with a `ddb:"result"` struct tag,
+// unmarshal results to that field.
+//
+// 3. Unmarshal the results directly to sb.
+//
+// Page(), Limit(), ConsistentRead() and Segment() can be provided as options.
+// Options which only apply to queries, such as Count(), return an error.
+func (c *Client) Scan(ctx context.Context, sb ScanBuilder, opts ...func(*QueryOpts)) (*ScanResult, error) {
+	qo := QueryOpts{}
+	for _, o := range opts {
+		o(&qo)
+	}
+
+	got, err := c.scanPage(ctx, sb, qo, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScanResult{
+		RawOutput: got,
+	}
+	if got.LastEvaluatedKey != nil {
+		s, err := c.tokenizer.MarshalToken(ctx, got.LastEvaluatedKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling LastEvaluatedKey to page token")
+		}
+		result.NextPage = s
+	}
+
+	// call the custom unmarshalling logic if the ScanBuilder implements it.
+	if rp, ok := sb.(ScanOutputUnmarshaler); ok {
+		err = rp.UnmarshalScanOutput(got)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	err = unmarshalItems(got.Items, sb, c.schema)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// scanPage calls the Scan API for a single page. The page starts after startKey if it is set,
+// or after the page token in the options otherwise.
+func (c *Client) scanPage(ctx context.Context, sb ScanBuilder, qo QueryOpts, startKey map[string]types.AttributeValue) (*dynamodb.ScanOutput, error) {
+	if err := qo.checkScan(); err != nil {
+		return nil, err
+	}
+
+	s, err := sb.BuildScan()
+	if err != nil {
+		return nil, err
+	}
+
+	// ensure that we have a tokenizer so that we don't nil panic
+	if c.tokenizer == nil {
+		return nil, errors.New("a page encoder must be set up to use pagination (call ddb.WithPageTokenizer when setting up the client to fix)")
+	}
+
+	if startKey != nil {
+		s.ExclusiveStartKey = startKey
+	} else if qo.PageToken != "" {
+		startKey, err := c.tokenizer.UnmarshalToken(ctx, qo.PageToken)
+		if err != nil {
+			return nil, errors.Wrap(err, "unmarshalling page start key")
+		}
+		s.ExclusiveStartKey = startKey
+	}
+	if qo.Limit > 0 {
+		s.Limit = &qo.Limit
+	}
+	if qo.Segment != nil {
+		s.Segment = qo.Segment
+		s.TotalSegments = &qo.TotalSegments
+	}
+	s.ConsistentRead = &qo.ConsistentRead
+
+	// scan builders don't necessarily know which table the client uses,
+	// so update the scan input to override the table name.
+	s.TableName = &c.table
+
+	return c.client.Scan(ctx, s)
+}
+
+// checkScan returns an error if query options which Scan doesn't support are set.
+func (qo QueryOpts) checkScan() error {
+	var unsupported []string
+	if qo.Reverse {
+		unsupported = append(unsupported, "Reverse()")
+	}
+	if len(qo.Projection) > 0 {
+		unsupported = append(unsupported, "Project()")
+	}
+	if qo.Count != nil {
+		unsupported = append(unsupported, "Count()")
+	}
+	if qo.MaxItems != 0 {
+		unsupported = append(unsupported, "MaxItems()")
+	}
+	if qo.MaxPages != 0 {
+		unsupported = append(unsupported, "MaxPages()")
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("%s can only be used with queries, not Scan or ScanAll", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// ScanAll reads every page of a scan, calling fn with the items in each page.
+// BuildScan is called for every page.
+//
+// By default, ScanAll reads the pages one after another. If TotalSegments(n) is
+// provided, the scan is split into n segments which are read by n workers in
+// parallel, and fn may be called concurrently. Segment(i, n) reads only
+// segment i.
+//
+// If fn returns an error, the scan is stopped and the error is returned.
+//
+//	err := db.ScanAll(ctx, &scanUsers{}, func(items []map[string]types.AttributeValue) error {
+//		var users []User
+//		err := attributevalue.UnmarshalListOfMaps(items, &users)
+//		...
+//	}, ddb.TotalSegments(4))
+func (c *Client) ScanAll(ctx context.Context, sb ScanBuilder, fn func(items []map[string]types.AttributeValue) error, opts ...func(*QueryOpts)) error {
+	qo := QueryOpts{}
+	for _, o := range opts {
+		o(&qo)
+	}
+
+	if qo.Segment != nil || qo.TotalSegments <= 1 {
+		return c.scanSegment(ctx, sb, fn, qo)
+	}
+
+	if qo.PageToken != "" {
+		return errors.New("a page token can't be used with TotalSegments, use Segment to scan a single segment instead")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i := int32(0); i < qo.TotalSegments; i++ {
+		segmentOpts := qo
+		segment := i
+		segmentOpts.Segment = &segment
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.scanSegment(ctx, sb, fn, segmentOpts)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					// stop the other workers.
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// scanSegment reads every page of a single scan segment.
+// Only the page token in the options is unmarshalled by the tokenizer. The following pages
+// start from the LastEvaluatedKey directly, to avoid tokenizing every page.
+func (c *Client) scanSegment(ctx context.Context, sb ScanBuilder, fn func(items []map[string]types.AttributeValue) error, qo QueryOpts) error {
+	var startKey map[string]types.AttributeValue
+	for {
+		got, err := c.scanPage(ctx, sb, qo, startKey)
+		if err != nil {
+			return err
+		}
+		err = fn(got.Items)
+		if err != nil {
+			return err
+		}
+		if got.LastEvaluatedKey == nil {
+			return nil
+		}
+		startKey = got.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,140 @@
+package ddb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type scanDynamoDB struct {
+	DynamoDBAPI
+
+	scans []*dynamodb.ScanInput
+	// pages are returned in order if set.
+	pages []*dynamodb.ScanOutput
+}
+
+func (f *scanDynamoDB) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	f.scans = append(f.scans, params)
+	if len(f.pages) > 0 {
+		out := f.pages[0]
+		f.pages = f.pages[1:]
+		return out, nil
+	}
+	return &dynamodb.ScanOutput{
+		Items: []map[string]types.AttributeValue{fakeThingItem("1")},
+	}, nil
+}
+
+type customScan struct {
+	Count int32
+}
+
+func (s *customScan) BuildScan() (*dynamodb.ScanInput, error) {
+	return &dynamodb.ScanInput{}, nil
+}
+
+func (s *customScan) UnmarshalScanOutput(out *dynamodb.ScanOutput) error {
+	s.Count = int32(len(out.Items))
+	return nil
+}
+
+func TestScanUnmarshalScanOutput(t *testing.T) {
+	f := &scanDynamoDB{}
+	c, err := New(context.Background(), "test-table", WithDynamoDBClient(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &customScan{}
+	_, err = c.Scan(context.Background(), s, Segment(2, 4), Limit(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(1), s.Count)
+
+	in := f.scans[0]
+	assert.Equal(t, "test-table", *in.TableName)
+	assert.Equal(t, int32(2), *in.Segment)
+	assert.Equal(t, int32(4), *in.TotalSegments)
+	assert.Equal(t, int32(10), *in.Limit)
+}
+
+func TestScanAllParallelWithPageToken(t *testing.T) {
+	c, err := New(context.Background(), "test-table", WithDynamoDBClient(&scanDynamoDB{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.ScanAll(context.Background(), &customScan{}, func(items []map[string]types.AttributeValue) error { return nil }, TotalSegments(2), Page("token"))
+	assert.Error(t, err)
+}
+
+func TestScanQueryOnlyOptions(t *testing.T) {
+	f := &scanDynamoDB{}
+	c, err := New(context.Background(), "test-table", WithDynamoDBClient(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Scan(context.Background(), &customScan{}, Reverse(), Count(new(int)))
+	assert.EqualError(t, err, "Reverse(), Count() can only be used with queries, not Scan or ScanAll")
+
+	err = c.ScanAll(context.Background(), &customScan{}, func(items []map[string]types.AttributeValue) error { return nil }, TotalSegments(2), MaxPages(1))
+	assert.EqualError(t, err, "MaxPages() can only be used with queries, not Scan or ScanAll")
+	assert.Empty(t, f.scans)
+}
+
+// countingTokenizer counts calls to the tokenizer it wraps.
+type countingTokenizer struct {
+	JSONTokenizer
+	marshalled, unmarshalled int
+}
+
+func (c *countingTokenizer) MarshalToken(ctx context.Context, item map[string]types.AttributeValue) (string, error) {
+	c.marshalled++
+	return c.JSONTokenizer.MarshalToken(ctx, item)
+}
+
+func (c *countingTokenizer) UnmarshalToken(ctx context.Context, s string) (map[string]types.AttributeValue, error) {
+	c.unmarshalled++
+	return c.JSONTokenizer.UnmarshalToken(ctx, s)
+}
+
+func TestScanAllDoesNotTokenizePages(t *testing.T) {
+	key := func(id string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "THING"}, "SK": &types.AttributeValueMemberS{Value: id}}
+	}
+	f := &scanDynamoDB{pages: []*dynamodb.ScanOutput{
+		{Items: []map[string]types.AttributeValue{fakeThingItem("1")}, LastEvaluatedKey: key("1")},
+		{Items: []map[string]types.AttributeValue{fakeThingItem("2")}, LastEvaluatedKey: key("2")},
+		{Items: []map[string]types.AttributeValue{fakeThingItem("3")}},
+	}}
+	tok := &countingTokenizer{}
+	c, err := New(context.Background(), "test-table", WithDynamoDBClient(f), WithPageTokenizer(tok))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, err := tok.JSONTokenizer.MarshalToken(context.Background(), key("0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pages int
+	err = c.ScanAll(context.Background(), &customScan{}, func(items []map[string]types.AttributeValue) error {
+		pages++
+		return nil
+	}, Page(start))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, pages)
+	// only the page token provided by the caller is unmarshalled.
+	assert.Equal(t, 0, tok.marshalled)
+	assert.Equal(t, 1, tok.unmarshalled)
+	assert.Equal(t, key("0"), f.scans[0].ExclusiveStartKey)
+	assert.Equal(t, key("1"), f.scans[1].ExclusiveStartKey)
+	assert.Equal(t, key("2"), f.scans[2].ExclusiveStartKey)
+}
@@ -0,0 +1,274 @@
+package ddb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TableSchema declares the key attribute names of a DynamoDB table.
+//
+// By default, the ddb package uses the key names of the Keys struct:
+// a primary key of PK and SK, and global secondary indexes named GSI1 to GSI4
+// with keys GSI1PK and GSI1SK to GSI4PK and GSI4SK. Provide a TableSchema with
+// WithTableSchema() to use the library with tables which use other names.
+type TableSchema struct {
+	// PartitionKey is the name of the table's partition key attribute.
+	PartitionKey string
+	// SortKey is the name of the table's sort key attribute.
+	// It may be empty if the table doesn't have a sort key,
+	// in which case the SK fields of Keys and GetKey are ignored.
+	SortKey string
+	// PartitionKeyType and SortKeyType are the DynamoDB types of the keys.
+	// If empty, the keys are strings (S). For number (N) keys, the key values
+	// in Keys and GetKey are the decimal representation of the number,
+	// for example strconv.FormatInt(n, 10). For binary (B) keys, they are
+	// the raw bytes converted to a string.
+	PartitionKeyType types.ScalarAttributeType
+	SortKeyType      types.ScalarAttributeType
+	// Indexes declares the table's global secondary indexes. Indexes[0]
+	// holds the GSI1PK and GSI1SK fields of Keys, Indexes[1] holds GSI2PK
+	// and GSI2SK, and so on. Up to four indexes can be declared.
+	Indexes []IndexSchema
+}
+
+// IndexSchema declares the name and key attribute names of a global secondary index.
+// The key types default to strings (S), in the same way as TableSchema.
+type IndexSchema struct {
+	Name             string
+	PartitionKey     string
+	SortKey          string
+	PartitionKeyType types.ScalarAttributeType
+	SortKeyType      types.ScalarAttributeType
+}
+
+// DefaultTableSchema returns the schema used by the ddb package
+// if a schema isn't provided with WithTableSchema().
+func DefaultTableSchema() TableSchema {
+	s := TableSchema{
+		PartitionKey: "PK",
+		SortKey:      "SK",
+	}
+	for i := 1; i <= 4; i++ {
+		s.Indexes = append(s.Indexes, IndexSchema{
+			Name:         fmt.Sprintf("GSI%d", i),
+			PartitionKey: fmt.Sprintf("GSI%dPK", i),
+			SortKey:      fmt.Sprintf("GSI%dSK", i),
+		})
+	}
+	return s
+}
+
+// WithTableSchema sets the key attribute names of the table.
+// All reads, writes and deletes derive their key attributes from the schema.
+//
+//	ddb.New(ctx, "my-table", ddb.WithTableSchema(ddb.TableSchema{
+//		PartitionKey: "pk",
+//		SortKey:      "sk",
+//		Indexes: []ddb.IndexSchema{
+//			{Name: "gsi1", PartitionKey: "gsi1pk", SortKey: "gsi1sk"},
+//		},
+//	}))
+func WithTableSchema(s TableSchema) func(*Client) {
+	return func(c *Client) {
+		c.schema = s
+	}
+}
+
+// Schema returns the key schema of the table that the client is configured to use.
+func (c *Client) Schema() TableSchema {
+	return c.schema
+}
+
+// Validate returns an error if the schema is missing key names.
+func (s TableSchema) Validate() error {
+	if s.PartitionKey == "" {
+		return errors.New("table schema must have a partition key")
+	}
+	if len(s.Indexes) > 4 {
+		return errors.New("table schema can declare at most 4 indexes")
+	}
+	for i, idx := range s.Indexes {
+		if idx.Name == "" || idx.PartitionKey == "" {
+			return fmt.Errorf("index %d in table schema must have a name and partition key", i+1)
+		}
+		if err := validKeyTypes(idx.PartitionKeyType, idx.SortKeyType); err != nil {
+			return fmt.Errorf("index %s: %w", idx.Name, err)
+		}
+	}
+	return validKeyTypes(s.PartitionKeyType, s.SortKeyType)
+}
+
+func validKeyTypes(kt ...types.ScalarAttributeType) error {
+	for _, t := range kt {
+		switch t {
+		case "", types.ScalarAttributeTypeS, types.ScalarAttributeTypeN, types.ScalarAttributeTypeB:
+		default:
+			return fmt.Errorf("invalid key type %q: key types must be S, N or B", t)
+		}
+	}
+	return nil
+}
+
+// keyValue returns the attribute value for a key of the given type.
+func keyValue(t types.ScalarAttributeType, v string) types.AttributeValue {
+	switch t {
+	case types.ScalarAttributeTypeN:
+		return &types.AttributeValueMemberN{Value: v}
+	case types.ScalarAttributeTypeB:
+		return &types.AttributeValueMemberB{Value: []byte(v)}
+	default:
+		return &types.AttributeValueMemberS{Value: v}
+	}
+}
+
+// normalizeKey returns k with any number keys in the canonical form
+// that DynamoDB returns them in, so that it can be compared with the
+// keys of items read from DynamoDB.
+func (s TableSchema) normalizeKey(k GetKey) GetKey {
+	if s.PartitionKeyType == types.ScalarAttributeTypeN {
+		k.PK = normalizeNumber(k.PK)
+	}
+	if s.SortKey != "" && s.SortKeyType == types.ScalarAttributeTypeN {
+		k.SK = normalizeNumber(k.SK)
+	}
+	return k
+}
+
+// normalizeNumber returns the canonical decimal representation of a number,
+// without leading or trailing zeros or an exponent. For example, "01", "1.0"
+// and "0.1e1" are all normalised to "1". Invalid numbers are returned unchanged.
+func normalizeNumber(n string) string {
+	s := n
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return n
+		}
+		exp = e
+		s = s[:i]
+	}
+	// digits holds the significant digits, and the value is digits * 10^exp.
+	digits := s
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits = s[:i] + s[i+1:]
+		exp -= len(s) - i - 1
+	}
+	if digits == "" {
+		return n
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return n
+		}
+	}
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "0"
+	}
+	trimmed := strings.TrimRight(digits, "0")
+	exp += len(digits) - len(trimmed)
+	digits = trimmed
+
+	var out string
+	switch point := len(digits) + exp; {
+	case exp >= 0:
+		out = digits + strings.Repeat("0", exp)
+	case point > 0:
+		out = digits[:point] + "." + digits[point:]
+	default:
+		out = "0." + strings.Repeat("0", -point) + digits
+	}
+	if neg {
+		out = "-" + out
+	}
+	return out
+}
+
+// keyString returns the value of a string, number or binary key attribute as a string.
+// It returns false if the attribute isn't a valid key type.
+func keyString(av types.AttributeValue) (string, bool) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value, true
+	case *types.AttributeValueMemberN:
+		return v.Value, true
+	case *types.AttributeValueMemberB:
+		return string(v.Value), true
+	}
+	return "", false
+}
+
+// Index returns the schema of the index with the given name.
+func (s TableSchema) Index(name string) (IndexSchema, bool) {
+	for _, idx := range s.Indexes {
+		if idx.Name == name {
+			return idx, true
+		}
+	}
+	return IndexSchema{}, false
+}
+
+// key returns the primary key attributes for a GetKey.
+func (s TableSchema) key(k GetKey) map[string]types.AttributeValue {
+	key := map[string]types.AttributeValue{
+		s.PartitionKey: keyValue(s.PartitionKeyType, k.PK),
+	}
+	if s.SortKey != "" {
+		key[s.SortKey] = keyValue(s.SortKeyType, k.SK)
+	}
+	return key
+}
+
+// getKey returns the primary key of a DynamoDB item.
+func (s TableSchema) getKey(item map[string]types.AttributeValue) GetKey {
+	var k GetKey
+	k.PK, _ = keyString(item[s.PartitionKey])
+	if s.SortKey != "" {
+		k.SK, _ = keyString(item[s.SortKey])
+	}
+	return k
+}
+
+// keyAttributes returns the key attributes of an item, named according to the schema.
+// Keys which are empty strings are omitted.
+// An error is returned if an index key is set for an index which isn't in the schema.
+func (s TableSchema) keyAttributes(keys Keys) (map[string]types.AttributeValue, error) {
+	attrs := map[string]types.AttributeValue{}
+	set := func(name string, t types.ScalarAttributeType, value string) {
+		if name != "" && value != "" {
+			attrs[name] = keyValue(t, value)
+		}
+	}
+	set(s.PartitionKey, s.PartitionKeyType, keys.PK)
+	set(s.SortKey, s.SortKeyType, keys.SK)
+
+	gsis := [][2]string{
+		{keys.GSI1PK, keys.GSI1SK},
+		{keys.GSI2PK, keys.GSI2SK},
+		{keys.GSI3PK, keys.GSI3SK},
+		{keys.GSI4PK, keys.GSI4SK},
+	}
+	for i, gsi := range gsis {
+		if gsi[0] == "" && gsi[1] == "" {
+			continue
+		}
+		if i >= len(s.Indexes) {
+			return nil, fmt.Errorf("keys for GSI%d were provided, but the table schema only declares %d indexes", i+1, len(s.Indexes))
+		}
+		idx := s.Indexes[i]
+		set(idx.PartitionKey, idx.PartitionKeyType, gsi[0])
+		set(idx.SortKey, idx.SortKeyType, gsi[1])
+	}
+	return attrs, nil
+}
@@ -0,0 +1,143 @@
+package ddb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableSchema_keyAttributes(t *testing.T) {
+	custom := TableSchema{
+		PartitionKey: "pk",
+		SortKey:      "sk",
+		Indexes: []IndexSchema{
+			{Name: "gsi1", PartitionKey: "gsi1pk", SortKey: "gsi1sk"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		schema  TableSchema
+		give    Keys
+		want    map[string]types.AttributeValue
+		wantErr bool
+	}{
+		{
+			name:   "default",
+			schema: DefaultTableSchema(),
+			give:   Keys{PK: "a", SK: "b", GSI2PK: "c", GSI2SK: "d"},
+			want: map[string]types.AttributeValue{
+				"PK":     &types.AttributeValueMemberS{Value: "a"},
+				"SK":     &types.AttributeValueMemberS{Value: "b"},
+				"GSI2PK": &types.AttributeValueMemberS{Value: "c"},
+				"GSI2SK": &types.AttributeValueMemberS{Value: "d"},
+			},
+		},
+		{
+			name:   "custom",
+			schema: custom,
+			give:   Keys{PK: "a", SK: "b", GSI1PK: "c"},
+			want: map[string]types.AttributeValue{
+				"pk":     &types.AttributeValueMemberS{Value: "a"},
+				"sk":     &types.AttributeValueMemberS{Value: "b"},
+				"gsi1pk": &types.AttributeValueMemberS{Value: "c"},
+			},
+		},
+		{
+			name:   "no sort key",
+			schema: TableSchema{PartitionKey: "id"},
+			give:   Keys{PK: "a", SK: "ignored"},
+			want: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: "a"},
+			},
+		},
+		{
+			name: "key types",
+			schema: TableSchema{
+				PartitionKey:     "id",
+				PartitionKeyType: types.ScalarAttributeTypeB,
+				SortKey:          "ts",
+				SortKeyType:      types.ScalarAttributeTypeN,
+				Indexes: []IndexSchema{
+					{Name: "byScore", PartitionKey: "board", SortKey: "score", SortKeyType: types.ScalarAttributeTypeN},
+				},
+			},
+			give: Keys{PK: "\x00\x01", SK: "1672531200", GSI1PK: "global", GSI1SK: "99.5"},
+			want: map[string]types.AttributeValue{
+				"id":    &types.AttributeValueMemberB{Value: []byte{0x00, 0x01}},
+				"ts":    &types.AttributeValueMemberN{Value: "1672531200"},
+				"board": &types.AttributeValueMemberS{Value: "global"},
+				"score": &types.AttributeValueMemberN{Value: "99.5"},
+			},
+		},
+		{
+			name:    "undeclared index",
+			schema:  custom,
+			give:    Keys{PK: "a", SK: "b", GSI2PK: "c"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.schema.keyAttributes(tt.give)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("keyAttributes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTableSchema_key(t *testing.T) {
+	s := TableSchema{PartitionKey: "pk", SortKey: "sk"}
+	key := s.key(GetKey{PK: "a", SK: "b"})
+	assert.Equal(t, map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "a"},
+		"sk": &types.AttributeValueMemberS{Value: "b"},
+	}, key)
+	assert.Equal(t, GetKey{PK: "a", SK: "b"}, s.getKey(key))
+
+	s = TableSchema{PartitionKey: "pk", SortKey: "sk", SortKeyType: types.ScalarAttributeTypeN}
+	key = s.key(GetKey{PK: "a", SK: "10"})
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "10"}, key["sk"])
+	assert.Equal(t, GetKey{PK: "a", SK: "10"}, s.getKey(key))
+
+	s = TableSchema{PartitionKey: "id"}
+	key = s.key(GetKey{PK: "a", SK: "b"})
+	assert.Equal(t, map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "a"}}, key)
+	assert.Equal(t, GetKey{PK: "a"}, s.getKey(key))
+}
+
+func TestTableSchema_Validate(t *testing.T) {
+	assert.NoError(t, DefaultTableSchema().Validate())
+	assert.Error(t, TableSchema{}.Validate())
+	assert.Error(t, TableSchema{PartitionKey: "pk", SortKeyType: "BOOL"}.Validate())
+	assert.Error(t, TableSchema{PartitionKey: "pk", Indexes: []IndexSchema{{PartitionKey: "gsi1pk"}}}.Validate())
+}
+
+func Test_normalizeNumber(t *testing.T) {
+	tests := []struct {
+		give string
+		want string
+	}{
+		{give: "1", want: "1"},
+		{give: "01", want: "1"},
+		{give: "1.0", want: "1"},
+		{give: "+1.50", want: "1.5"},
+		{give: "-0.0100", want: "-0.01"},
+		{give: "1e2", want: "100"},
+		{give: "12.5E-3", want: "0.0125"},
+		{give: ".5", want: "0.5"},
+		{give: "-0", want: "0"},
+		{give: "1200", want: "1200"},
+		{give: "abc", want: "abc"},
+		{give: "1e", want: "1e"},
+		{give: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.give, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeNumber(tt.give))
+		})
+	}
+}
@@ -0,0 +1,442 @@
+package ddb
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tagged wraps a pointer to a struct whose keys are declared with `ddb` struct tags,
+// so that it can be passed to methods which accept a Keyer.
+//
+// Key struct tags name the key and a template for its value. Fields are
+// referenced in the template by their Go name:
+//
+//	type User struct {
+//		ID        string    `ddb:"pk,template=USER#{ID}"`
+//		CreatedAt time.Time `ddb:"gsi1sk,template={CreatedAt}"`
+//		_         struct{}  `ddb:"sk,template=PROFILE"`
+//	}
+//
+//	db.Put(ctx, ddb.Tagged(&user))
+//
+// If the template is omitted, the key is the value of the tagged field.
+// Keys which can't be attached to a field of their own, such as a constant
+// sort key, can be declared on blank (_) fields. Keys are named after the
+// fields of the Keys struct: pk, sk and gsi1pk to gsi4sk.
+//
+// Strings, integers, floats, bools and types implementing encoding.TextMarshaler
+// (such as time.Time) can be used in templates. If a field referenced
+// by a template is an empty string, the key is omitted.
+//
+// When an item is read back from DynamoDB, the fields referenced by
+// its key templates are parsed from the keys. This allows fields which are
+// only stored as part of a key to be excluded with a `dynamodbav:"-"` tag.
+// Templates which reference more than one field must separate them with a
+// delimiter which doesn't appear in the field values.
+//
+// Items which implement Keyer use DDBKeys() rather than struct tags.
+func Tagged(item interface{}) Keyer {
+	return taggedItem{item: item}
+}
+
+// Unwrap returns the item wrapped by Tagged().
+// Other items are returned unchanged.
+func Unwrap(item interface{}) interface{} {
+	if t, ok := item.(taggedItem); ok {
+		return t.item
+	}
+	return item
+}
+
+// TaggedKeys returns the keys of an item declared with `ddb` struct tags.
+// It can be used to implement Keyer:
+//
+//	func (u User) DDBKeys() (ddb.Keys, error) {
+//		return ddb.TaggedKeys(u)
+//	}
+func TaggedKeys(item interface{}) (Keys, error) {
+	v := reflect.Indirect(reflect.ValueOf(item))
+	if v.Kind() != reflect.Struct {
+		return Keys{}, fmt.Errorf("%T must be a struct to use key struct tags", item)
+	}
+	tk, err := keyTagsOf(v.Type())
+	if err != nil {
+		return Keys{}, err
+	}
+	if len(tk.keys) == 0 {
+		return Keys{}, fmt.Errorf("%T does not implement ddb.Keyer or declare keys with ddb struct tags", item)
+	}
+
+	var keys Keys
+	fields := keys.fields()
+	for _, k := range tk.keys {
+		s, err := k.template.format(v)
+		if err != nil {
+			return Keys{}, fmt.Errorf("formatting %s of %T: %w", keyNames[k.index], item, err)
+		}
+		*fields[k.index] = s
+	}
+	return keys, nil
+}
+
+// taggedItem is an item whose keys are declared with struct tags.
+type taggedItem struct {
+	item interface{}
+}
+
+func (t taggedItem) DDBKeys() (Keys, error) {
+	return TaggedKeys(t.item)
+}
+
+// itemKeys returns the keys of an item, using DDBKeys() if it implements Keyer.
+func itemKeys(item interface{}) (Keys, error) {
+	if k, ok := item.(Keyer); ok {
+		return k.DDBKeys()
+	}
+	return TaggedKeys(item)
+}
+
+// keyNames are the tag names of the Keys fields, in the order returned by Keys.fields().
+var keyNames = [...]string{"pk", "sk", "gsi1pk", "gsi1sk", "gsi2pk", "gsi2sk", "gsi3pk", "gsi3sk", "gsi4pk", "gsi4sk"}
+
+func (k *Keys) fields() [len(keyNames)]*string {
+	return [...]*string{&k.PK, &k.SK, &k.GSI1PK, &k.GSI1SK, &k.GSI2PK, &k.GSI2SK, &k.GSI3PK, &k.GSI3SK, &k.GSI4PK, &k.GSI4SK}
+}
+
+// keyAttributeNames returns the attribute names of the Keys fields,
+// in the order returned by Keys.fields(). Names of undeclared indexes are empty.
+func (s TableSchema) keyAttributeNames() [len(keyNames)]string {
+	names := [len(keyNames)]string{s.PartitionKey, s.SortKey}
+	for i, idx := range s.Indexes {
+		if i >= 4 {
+			break
+		}
+		names[2+i*2] = idx.PartitionKey
+		names[3+i*2] = idx.SortKey
+	}
+	return names
+}
+
+// keyTags are the keys declared with struct tags on a type.
+type keyTags struct {
+	keys []taggedKey
+	err  error
+}
+
+type taggedKey struct {
+	// index of the key in keyNames.
+	index    int
+	template keyTemplate
+}
+
+// keyTagCache holds the *keyTags of each struct type.
+var keyTagCache sync.Map
+
+// keyTagsOf returns the keys declared with struct tags on t.
+// The result is cached, as reflecting over the fields of
+// the type is repeated for every item which is read or written.
+func keyTagsOf(t reflect.Type) (*keyTags, error) {
+	if cached, ok := keyTagCache.Load(t); ok {
+		tk := cached.(*keyTags)
+		return tk, tk.err
+	}
+	tk := parseKeyTags(t)
+	keyTagCache.Store(t, tk)
+	return tk, tk.err
+}
+
+func parseKeyTags(t reflect.Type) *keyTags {
+	tk := &keyTags{}
+	seen := map[int]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("ddb")
+		if !ok {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		index := -1
+		for j, kn := range keyNames {
+			if strings.EqualFold(name, kn) {
+				index = j
+			}
+		}
+		if index == -1 {
+			// other ddb tags such as "result" and "version" aren't keys.
+			continue
+		}
+		if seen[index] {
+			tk.err = fmt.Errorf("%s declares the %s key more than once", t, keyNames[index])
+			return tk
+		}
+		seen[index] = true
+
+		tmpl := "{" + f.Name + "}"
+		if opts != "" {
+			if !strings.HasPrefix(opts, "template=") {
+				tk.err = fmt.Errorf("invalid ddb struct tag on %s.%s: %q", t, f.Name, tag)
+				return tk
+			}
+			tmpl = strings.TrimPrefix(opts, "template=")
+		} else if f.Name == "_" {
+			tk.err = fmt.Errorf("the %s key of %s must have a template", keyNames[index], t)
+			return tk
+		}
+
+		kt, err := parseKeyTemplate(t, tmpl)
+		if err != nil {
+			tk.err = fmt.Errorf("%s key of %s: %w", keyNames[index], t, err)
+			return tk
+		}
+		tk.keys = append(tk.keys, taggedKey{index: index, template: kt})
+	}
+	return tk
+}
+
+// keyTemplate is a parsed key template such as "USER#{ID}".
+type keyTemplate []templateSegment
+
+// templateSegment is either a literal string or a reference to a field.
+type templateSegment struct {
+	literal string
+	field   []int
+	name    string
+}
+
+func parseKeyTemplate(t reflect.Type, s string) (keyTemplate, error) {
+	var kt keyTemplate
+	for s != "" {
+		start := strings.IndexByte(s, '{')
+		if start == -1 {
+			kt = append(kt, templateSegment{literal: s})
+			break
+		}
+		if start > 0 {
+			kt = append(kt, templateSegment{literal: s[:start]})
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end == -1 {
+			return nil, fmt.Errorf("unclosed '{' in template %q", s)
+		}
+		name := s[start+1 : start+end]
+		f, ok := t.FieldByName(name)
+		if !ok || !f.IsExported() {
+			return nil, fmt.Errorf("template references unknown field %q", name)
+		}
+		if !formattable(f.Type) {
+			return nil, fmt.Errorf("field %s of type %s can't be used in a key template", name, f.Type)
+		}
+		if len(kt) > 0 && kt[len(kt)-1].literal == "" {
+			return nil, fmt.Errorf("fields %s and %s in template must be separated by a delimiter", kt[len(kt)-1].name, name)
+		}
+		kt = append(kt, templateSegment{field: f.Index, name: name})
+		s = s[start+end+1:]
+	}
+	return kt, nil
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func formattable(t reflect.Type) bool {
+	if t.Implements(textMarshalerType) && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// format renders the template using the fields of v.
+// It returns an empty string if any referenced field is an empty string.
+func (kt keyTemplate) format(v reflect.Value) (string, error) {
+	var b strings.Builder
+	for _, seg := range kt {
+		if seg.field == nil {
+			b.WriteString(seg.literal)
+			continue
+		}
+		s, err := formatField(v.FieldByIndex(seg.field))
+		if err != nil {
+			return "", err
+		}
+		if s == "" {
+			return "", nil
+		}
+		b.WriteString(s)
+	}
+	return b.String(), nil
+}
+
+func formatField(f reflect.Value) (string, error) {
+	if tm, ok := f.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch f.Kind() {
+	case reflect.String:
+		return f.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(f.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(f.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(f.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(f.Float(), 'f', -1, f.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported key field type %s", f.Type())
+}
+
+// parse sets the fields of v which are referenced by the template from the key s.
+func (kt keyTemplate) parse(s string, v reflect.Value) error {
+	rest := s
+	for i, seg := range kt {
+		if seg.field == nil {
+			if !strings.HasPrefix(rest, seg.literal) {
+				return fmt.Errorf("key %q does not match template", s)
+			}
+			rest = rest[len(seg.literal):]
+			continue
+		}
+		value := rest
+		if i+1 < len(kt) {
+			end := strings.Index(rest, kt[i+1].literal)
+			if end == -1 {
+				return fmt.Errorf("key %q does not match template", s)
+			}
+			value = rest[:end]
+		}
+		rest = rest[len(value):]
+		if err := parseField(value, v.FieldByIndex(seg.field)); err != nil {
+			return fmt.Errorf("parsing %s from key %q: %w", seg.name, s, err)
+		}
+	}
+	if rest != "" {
+		return fmt.Errorf("key %q does not match template", s)
+	}
+	return nil
+}
+
+func parseField(s string, f reflect.Value) error {
+	if tu, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(s))
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported key field type %s", f.Type())
+	}
+	return nil
+}
+
+// parseTaggedKeys sets the fields referenced by the key templates of v,
+// which must be an addressable struct, from the keys of a DynamoDB item.
+func parseTaggedKeys(item map[string]types.AttributeValue, v reflect.Value, schema TableSchema) error {
+	tk, err := keyTagsOf(v.Type())
+	if err != nil {
+		return err
+	}
+	names := schema.keyAttributeNames()
+	for _, k := range tk.keys {
+		av, ok := item[names[k.index]]
+		if !ok || names[k.index] == "" {
+			continue
+		}
+		s, ok := keyString(av)
+		if !ok {
+			continue
+		}
+		if err := k.template.parse(s, v); err != nil {
+			return fmt.Errorf("%s key of %s: %w", keyNames[k.index], v.Type(), err)
+		}
+	}
+	return nil
+}
+
+// unmarshalItem unmarshals a DynamoDB item to out, parsing any keys
+// declared with struct tags back into their fields.
+func unmarshalItem(item map[string]types.AttributeValue, out interface{}, schema TableSchema) error {
+	out = Unwrap(out)
+	err := attributevalue.UnmarshalMap(item, out)
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return parseTaggedKeys(item, v.Elem(), schema)
+}
+
+// unmarshalList unmarshals DynamoDB items to out, which must be a pointer to a slice.
+// Keys declared with struct tags on the slice elements are parsed back into their fields.
+func unmarshalList(items []map[string]types.AttributeValue, out interface{}, schema TableSchema) error {
+	err := attributevalue.UnmarshalListOfMaps(items, out)
+	if err != nil {
+		return err
+	}
+	v := reflect.Indirect(reflect.ValueOf(out))
+	if v.Kind() != reflect.Slice || v.Len() != len(items) {
+		return nil
+	}
+	elemType := v.Type().Elem()
+	if elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil
+	}
+	if tk, err := keyTagsOf(elemType); err != nil || len(tk.keys) == 0 {
+		return err
+	}
+	for i := range items {
+		elem := reflect.Indirect(v.Index(i))
+		if !elem.IsValid() {
+			continue
+		}
+		if err := parseTaggedKeys(items[i], elem, schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package ddb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type taggedUser struct {
+	ID        string    `ddb:"pk,template=USER#{ID}" dynamodbav:"-"`
+	CreatedAt time.Time `ddb:"gsi1sk,template={CreatedAt}"`
+	Org       string    `ddb:"gsi1pk,template=ORG#{Org}"`
+	_         struct{}  `ddb:"sk,template=PROFILE"`
+	Name      string
+}
+
+type taggedMembership struct {
+	Org    string `ddb:"pk,template=ORG#{Org}#USER#{UserID}" dynamodbav:"-"`
+	UserID string `dynamodbav:"-"`
+	Rank   int    `ddb:"sk" dynamodbav:"-"`
+}
+
+func TestTaggedKeys(t *testing.T) {
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		give    interface{}
+		want    Keys
+		wantErr bool
+	}{
+		{
+			name: "templates",
+			give: taggedUser{ID: "1", Org: "acme", CreatedAt: created},
+			want: Keys{PK: "USER#1", SK: "PROFILE", GSI1PK: "ORG#acme", GSI1SK: "2023-01-02T03:04:05Z"},
+		},
+		{
+			name: "pointer",
+			give: &taggedUser{ID: "1", CreatedAt: created},
+			want: Keys{PK: "USER#1", SK: "PROFILE", GSI1SK: "2023-01-02T03:04:05Z"},
+		},
+		{
+			name: "multiple fields",
+			give: taggedMembership{Org: "acme", UserID: "1", Rank: 10},
+			want: Keys{PK: "ORG#acme#USER#1", SK: "10"},
+		},
+		{
+			name:    "no tags",
+			give:    struct{ ID string }{},
+			wantErr: true,
+		},
+		{
+			name: "unknown field",
+			give: struct {
+				ID string `ddb:"pk,template=USER#{Missing}"`
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "adjacent fields",
+			give: struct {
+				A string `ddb:"pk,template={A}{B}"`
+				B string
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "duplicate key",
+			give: struct {
+				A string `ddb:"pk"`
+				B string `ddb:"PK"`
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "unsupported type",
+			give: struct {
+				A []string `ddb:"pk"`
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "invalid option",
+			give: struct {
+				A string `ddb:"pk,omitempty"`
+			}{},
+			wantErr: true,
+		},
+		{
+			name:    "not a struct",
+			give:    "USER#1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TaggedKeys(tt.give)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TaggedKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMarshalTaggedItem(t *testing.T) {
+	u := taggedUser{ID: "1", Name: "Alice", Org: "acme", CreatedAt: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	got, err := marshalItem(Tagged(&u), DefaultTableSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "USER#1"}, got["PK"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "PROFILE"}, got["SK"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "ORG#acme"}, got["GSI1PK"])
+	assert.NotContains(t, got, "ID")
+
+	// items which aren't Keyers can be marshalled directly.
+	direct, err := marshalItem(u, DefaultTableSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, got, direct)
+
+	// the ID is parsed back from the partition key.
+	var out taggedUser
+	err = unmarshalItem(got, Tagged(&out), DefaultTableSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, u, out)
+
+	var list []taggedUser
+	err = unmarshalList([]map[string]types.AttributeValue{got, got}, &list, DefaultTableSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []taggedUser{u, u}, list)
+}
+
+func TestUnmarshalTaggedKeys(t *testing.T) {
+	s := TableSchema{PartitionKey: "pk", SortKey: "sk", SortKeyType: types.ScalarAttributeTypeN}
+
+	var m taggedMembership
+	err := unmarshalItem(map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "ORG#acme#USER#1"},
+		"sk": &types.AttributeValueMemberN{Value: "10"},
+	}, &m, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, taggedMembership{Org: "acme", UserID: "1", Rank: 10}, m)
+
+	// keys which don't match the template return an error.
+	err = unmarshalItem(map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "TEAM#acme"},
+	}, &m, s)
+	assert.Error(t, err)
+
+	err = unmarshalItem(map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "ORG#acme#USER#1"},
+		"sk": &types.AttributeValueMemberN{Value: "first"},
+	}, &m, s)
+	assert.Error(t, err)
+}
+
+func TestKeyTagsCached(t *testing.T) {
+	typ := reflect.TypeOf(taggedUser{})
+	a, err := keyTagsOf(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := keyTagsOf(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Same(t, a, b)
+}
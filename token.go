@@ -0,0 +1,65 @@
+package ddb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// tokenValue is the JSON representation of a key attribute in a page token.
+// It uses the same type descriptors as the DynamoDB JSON format, so
+// string, number and binary keys can be distinguished.
+type tokenValue struct {
+	S *string `json:",omitempty"`
+	N *string `json:",omitempty"`
+	B []byte  `json:",omitempty"`
+	// Value is the format used by tokens issued by earlier
+	// versions of this package, which only supported string keys.
+	Value *string `json:",omitempty"`
+}
+
+// marshalTokenItem marshals a LastEvaluatedKey to JSON.
+// It is shared between the tokenizers in this package.
+func marshalTokenItem(item map[string]types.AttributeValue) ([]byte, error) {
+	tmp := make(map[string]tokenValue, len(item))
+	for k, v := range item {
+		switch v := v.(type) {
+		case *types.AttributeValueMemberS:
+			tmp[k] = tokenValue{S: &v.Value}
+		case *types.AttributeValueMemberN:
+			tmp[k] = tokenValue{N: &v.Value}
+		case *types.AttributeValueMemberB:
+			tmp[k] = tokenValue{B: v.Value}
+		default:
+			return nil, fmt.Errorf("unsupported key type %T for attribute %s in page token", v, k)
+		}
+	}
+	return json.Marshal(tmp)
+}
+
+// unmarshalTokenItem unmarshals a LastEvaluatedKey from JSON.
+func unmarshalTokenItem(b []byte) (map[string]types.AttributeValue, error) {
+	var tmp map[string]tokenValue
+	err := json.Unmarshal(b, &tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]types.AttributeValue, len(tmp))
+	for k, v := range tmp {
+		switch {
+		case v.S != nil:
+			out[k] = &types.AttributeValueMemberS{Value: *v.S}
+		case v.N != nil:
+			out[k] = &types.AttributeValueMemberN{Value: *v.N}
+		case v.B != nil:
+			out[k] = &types.AttributeValueMemberB{Value: v.B}
+		case v.Value != nil:
+			out[k] = &types.AttributeValueMemberS{Value: *v.Value}
+		default:
+			return nil, fmt.Errorf("invalid value for attribute %s in page token", k)
+		}
+	}
+	return out, nil
+}
@@ -2,7 +2,6 @@ package ddb
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
@@ -14,7 +13,7 @@ func (e *JSONTokenizer) MarshalToken(ctx context.Context, item map[string]types.
 		return "", nil
 	}
 
-	b, err := json.Marshal(item)
+	b, err := marshalTokenItem(item)
 	if err != nil {
 		return "", err
 	}
@@ -26,19 +25,5 @@ func (e *JSONTokenizer) UnmarshalToken(ctx context.Context, s string) (map[strin
 		return nil, nil
 	}
 
-	var tmp map[string]*types.AttributeValueMemberS
-	err := json.Unmarshal([]byte(s), &tmp)
-	if err != nil {
-		return nil, err
-	}
-
-	// we can't use `tmp` as the return type, so copy the values over
-	// into a new map of the right type.
-	out := make(map[string]types.AttributeValue, len(tmp))
-
-	for k, v := range tmp {
-		out[k] = v
-	}
-
-	return out, nil
+	return unmarshalTokenItem([]byte(s))
 }
@@ -1,9 +1,35 @@
 package ddb
 
 import (
+	"context"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestJSONEncoder(t *testing.T) {
 	runEncoderTests(t, &JSONTokenizer{}, encoderTestCases)
 }
+
+func TestJSONEncoderLegacyToken(t *testing.T) {
+	// tokens issued before key types were supported only contained strings.
+	got, err := (&JSONTokenizer{}).UnmarshalToken(context.Background(), `{"PK":{"Value":"1"},"SK":{"Value":"2"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "1"},
+		"SK": &types.AttributeValueMemberS{Value: "2"},
+	}, got)
+}
+
+func TestJSONEncoderUnsupportedType(t *testing.T) {
+	_, err := (&JSONTokenizer{}).MarshalToken(context.Background(), map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberBOOL{Value: true},
+	})
+	assert.Error(t, err)
+
+	_, err = (&JSONTokenizer{}).UnmarshalToken(context.Background(), `{"PK":{}}`)
+	assert.Error(t, err)
+}
@@ -3,7 +3,6 @@ package ddb
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/kms"
@@ -22,7 +21,7 @@ func (e *KMSTokenizer) MarshalToken(ctx context.Context, item map[string]types.A
 		return "", nil
 	}
 
-	b, err := json.Marshal(item)
+	b, err := marshalTokenItem(item)
 	if err != nil {
 		return "", err
 	}
@@ -58,21 +57,7 @@ func (e *KMSTokenizer) UnmarshalToken(ctx context.Context, s string) (map[string
 		return map[string]types.AttributeValue{}, err
 	}
 
-	var tmp map[string]*types.AttributeValueMemberS
-	err = json.Unmarshal([]byte(result.Plaintext), &tmp)
-	if err != nil {
-		return nil, err
-	}
-
-	// we can't use `tmp` as the return type, so copy the values over
-	// into a new map of the right type.
-	out := make(map[string]types.AttributeValue, len(tmp))
-
-	for k, v := range tmp {
-		out[k] = v
-	}
-
-	return out, nil
+	return unmarshalTokenItem(result.Plaintext)
 }
 
 func NewKMSTokenizer(ctx context.Context, key string) (*KMSTokenizer, error) {
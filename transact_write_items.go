@@ -2,72 +2,480 @@ package ddb
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// MaxTransactionItems is the maximum number of operations in a transaction.
+const MaxTransactionItems = 100
+
+// MaxTransactionSize is the maximum total size of the items in a transaction, in bytes.
+const MaxTransactionSize = 4 * 1024 * 1024
+
 // TransactWriteItem is a wrapper over the DynamoDB TransactWriteItem type.
-// Currently, only the Put option is exposed. The API supports other operations
-// which can be added to this struct.
+// The Put, Delete, Update and ConditionCheck operations are supported.
+//
+// Exactly one operation must be set on each TransactWriteItem.
 //
 // see: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/transaction-apis.html
 type TransactWriteItem struct {
 	Put    Keyer
 	Delete Keyer
+	Update *UpdateItem
+	// ConditionCheck checks the condition in Opts against the item with
+	// the given key, without writing to the item.
+	ConditionCheck *GetKey
+	// Opts customise the operation, for example with a condition
+	// which must be met for the transaction to succeed.
+	// ReturnValues isn't supported in transactions.
+	Opts WriteOpts
+}
+
+// UpdateItem is a partial update to the item with the given key.
+type UpdateItem struct {
+	Key    GetKey
+	Update UpdateBuilder
+}
+
+// TransactionOpts customise how a transaction is written.
+type TransactionOpts struct {
+	// ClientRequestToken makes the transaction idempotent. If the transaction is repeated with
+	// the same token within 10 minutes, DynamoDB doesn't write the items again.
+	// If it is empty, a token is generated when the transaction is written.
+	ClientRequestToken string
+	// MaxConflictRetries is the number of times the transaction is retried if it is cancelled
+	// only because of conflicts with other transactions. Retries use exponential backoff
+	// and the same ClientRequestToken, so they are safe even if an earlier attempt succeeded.
+	MaxConflictRetries int
+}
+
+// ClientRequestToken sets the idempotency token for the transaction.
+// Use NewClientRequestToken to generate a token which can be stored and reused.
+func ClientRequestToken(token string) func(*TransactionOpts) {
+	return func(to *TransactionOpts) {
+		to.ClientRequestToken = token
+	}
+}
+
+// RetryOnConflict retries the transaction up to maxRetries times if it is cancelled
+// only because of conflicts with other transactions.
+func RetryOnConflict(maxRetries int) func(*TransactionOpts) {
+	return func(to *TransactionOpts) {
+		to.MaxConflictRetries = maxRetries
+	}
+}
+
+// NewClientRequestToken generates a random idempotency token for a transaction.
+func NewClientRequestToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// TransactWriteItems calls the TransactWriteItems API to write items atomically.
+//
+// Each operation may have a condition set in its Opts. If any condition isn't met,
+// none of the writes are made and the error matches ErrConditionFailed.
+//
+// The transaction is validated before calling the API, and an error matching
+// ErrInvalidTransaction is returned if it breaks the API's limits. See ValidateTransactWriteItems.
+//
+// Options can set the idempotency token for the transaction, and retry it if it is cancelled
+// because of conflicts with other transactions. See ClientRequestToken and RetryOnConflict.
+//
+// If DynamoDB cancels the transaction, a *TransactionError is returned listing the
+// operations which caused it to be cancelled.
+//
+// Versioned items are written with optimistic locking. If any of them have been
+// modified concurrently, the transaction is cancelled and the error matches ErrVersionConflict.
+func (c *Client) TransactWriteItems(ctx context.Context, tx []TransactWriteItem, opts ...func(*TransactionOpts)) error {
+	to := TransactionOpts{}
+	for _, o := range opts {
+		o(&to)
+	}
+	if to.MaxConflictRetries < 0 {
+		return errors.New("transaction conflict retries must not be negative")
+	}
+
+	twi, versions, err := c.buildTransactWriteItemsInput(tx)
+	if err != nil {
+		return err
+	}
+
+	// the SDK generates a token for each call if one isn't set, so generate it
+	// here to ensure that retries use the same token.
+	token := to.ClientRequestToken
+	if token == "" {
+		token, err = NewClientRequestToken()
+		if err != nil {
+			return err
+		}
+	}
+	twi.ClientRequestToken = &token
+
+	for attempt := 0; ; attempt++ {
+		_, err = c.client.TransactWriteItems(ctx, twi)
+		if err == nil {
+			break
+		}
+		err = transactionError(err, tx, versions)
+		var te *TransactionError
+		if attempt >= to.MaxConflictRetries || !errors.As(err, &te) || !te.conflictOnly() {
+			return err
+		}
+		if err := sleep(ctx, backoff(attempt)); err != nil {
+			return err
+		}
+	}
+
+	for i, v := range versions {
+		// only puts increment the version.
+		if v != nil && tx[i].Put != nil {
+			v.update()
+		}
+	}
+	return nil
 }
 
-func (c *Client) TransactWriteItems(ctx context.Context, tx []TransactWriteItem) error {
-	twi := dynamodb.TransactWriteItemsInput{
+// ValidateTransactWriteItems checks the transaction against the limits of the TransactWriteItems API,
+// without calling the API. TransactWriteItems always validates the transaction before calling the API.
+//
+// An error matching ErrInvalidTransaction is returned if the transaction is empty, has more than
+// MaxTransactionItems operations, has more than one operation on the same item,
+// or is larger than MaxTransactionSize.
+func (c *Client) ValidateTransactWriteItems(tx []TransactWriteItem) error {
+	_, _, err := c.buildTransactWriteItemsInput(tx)
+	return err
+}
+
+// buildTransactWriteItemsInput builds and validates the TransactWriteItems input. It also returns the
+// versions of any items using optimistic locking, indexed by their position in the transaction.
+func (c *Client) buildTransactWriteItemsInput(tx []TransactWriteItem) (*dynamodb.TransactWriteItemsInput, []*itemVersion, error) {
+	if len(tx) == 0 {
+		return nil, nil, fmt.Errorf("%w: transaction has no operations", ErrInvalidTransaction)
+	}
+	if len(tx) > MaxTransactionItems {
+		return nil, nil, fmt.Errorf("%w: transaction has %d operations, which is more than the limit of %d", ErrInvalidTransaction, len(tx), MaxTransactionItems)
+	}
+
+	twi := &dynamodb.TransactWriteItemsInput{
 		TransactItems: make([]types.TransactWriteItem, len(tx)),
 	}
+	versions := make([]*itemVersion, len(tx))
 
 	for i := range tx {
-		// a transaction must be either a put or a delete, not both.
+		// a transaction entry must have exactly one operation.
 		entry := tx[i]
-		if entry.Put == nil && entry.Delete == nil {
-			return errors.New("no operation defined for transaction")
+		var ops int
+		for _, defined := range []bool{entry.Put != nil, entry.Delete != nil, entry.Update != nil, entry.ConditionCheck != nil} {
+			if defined {
+				ops++
+			}
 		}
-
-		if entry.Put != nil && entry.Delete != nil {
-			return errors.New("both Put and Delete operations were defined for a transaction")
+		if ops == 0 {
+			return nil, nil, fmt.Errorf("%w: %s has no operation defined", ErrInvalidTransaction, entry.describe(i))
+		}
+		if ops > 1 {
+			return nil, nil, fmt.Errorf("%w: %s has multiple operations defined, only one of Put, Delete, Update or ConditionCheck may be set", ErrInvalidTransaction, entry.describe(i))
+		}
+		if entry.Opts.ReturnValues != "" {
+			return nil, nil, fmt.Errorf("%w: %s uses ReturnNew or ReturnOld, which are not supported in transactions", ErrInvalidTransaction, entry.describe(i))
 		}
+		condition := entry.Opts.buildCondition(c.schema)
 
 		if entry.Put != nil {
-			item, err := marshalItem(entry.Put)
+			item, err := marshalItem(entry.Put, c.schema)
 			if err != nil {
-				return err
+				return nil, nil, err
+			}
+			version, err := versionOf(entry.Put)
+			if err != nil {
+				return nil, nil, err
+			}
+			put := &types.Put{
+				Item:      item,
+				TableName: &c.table,
+			}
+			if version != nil {
+				item[version.attr] = version.next()
+				condition = condition.and(version.condition())
+				versions[i] = version
 			}
+			put.ConditionExpression, put.ExpressionAttributeNames, put.ExpressionAttributeValues = conditionArgs(condition)
 			twi.TransactItems[i] = types.TransactWriteItem{
-				Put: &types.Put{
-					Item:      item,
-					TableName: &c.table,
-				},
+				Put: put,
 			}
 		} else if entry.Delete != nil {
+			version, err := versionOf(entry.Delete)
+			if err != nil {
+				return nil, nil, err
+			}
 			keys, err := entry.Delete.DDBKeys()
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
 
-			keyAttrs, err := attributevalue.MarshalMap(keys)
+			del := &types.Delete{
+				Key:       c.schema.key(GetKey{PK: keys.PK, SK: keys.SK}),
+				TableName: &c.table,
+			}
+			if version != nil {
+				condition = condition.and(version.condition())
+				versions[i] = version
+			}
+			del.ConditionExpression, del.ExpressionAttributeNames, del.ExpressionAttributeValues = conditionArgs(condition)
+			twi.TransactItems[i] = types.TransactWriteItem{
+				Delete: del,
+			}
+		} else if entry.Update != nil {
+			if entry.Update.Update == nil {
+				return nil, nil, fmt.Errorf("%w: %s has no update defined", ErrInvalidTransaction, entry.describe(i))
+			}
+			in, err := c.buildUpdateItemInput(entry.Update.Key, entry.Update.Update, entry.Opts)
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
 			twi.TransactItems[i] = types.TransactWriteItem{
-				Delete: &types.Delete{
-					Key: map[string]types.AttributeValue{
-						"PK": keyAttrs["PK"],
-						"SK": keyAttrs["SK"],
-					},
-					TableName: &c.table,
+				Update: &types.Update{
+					Key:                       in.Key,
+					TableName:                 in.TableName,
+					UpdateExpression:          in.UpdateExpression,
+					ConditionExpression:       in.ConditionExpression,
+					ExpressionAttributeNames:  in.ExpressionAttributeNames,
+					ExpressionAttributeValues: in.ExpressionAttributeValues,
 				},
 			}
+		} else if entry.ConditionCheck != nil {
+			if condition == nil {
+				return nil, nil, fmt.Errorf("%w: %s has no condition defined", ErrInvalidTransaction, entry.describe(i))
+			}
+			check := &types.ConditionCheck{
+				Key:       c.schema.key(*entry.ConditionCheck),
+				TableName: &c.table,
+			}
+			check.ConditionExpression, check.ExpressionAttributeNames, check.ExpressionAttributeValues = conditionArgs(condition)
+			twi.TransactItems[i] = types.TransactWriteItem{
+				ConditionCheck: check,
+			}
 		}
 	}
 
-	_, err := c.client.TransactWriteItems(ctx, &twi)
-	return err
+	err := c.validateTransactItems(tx, twi.TransactItems)
+	if err != nil {
+		return nil, nil, err
+	}
+	return twi, versions, nil
+}
+
+// validateTransactItems checks that no item has more than one operation,
+// and that the transaction isn't larger than MaxTransactionSize.
+func (c *Client) validateTransactItems(tx []TransactWriteItem, items []types.TransactWriteItem) error {
+	// seen holds the index of the operation on each item.
+	seen := map[GetKey]int{}
+	sizes := make([]int, len(items))
+	var total, largest int
+	for i, item := range items {
+		var key map[string]types.AttributeValue
+		switch {
+		case item.Put != nil:
+			key = item.Put.Item
+			sizes[i] = itemSize(item.Put.Item)
+		case item.Delete != nil:
+			key = item.Delete.Key
+			sizes[i] = itemSize(item.Delete.Key)
+		case item.Update != nil:
+			key = item.Update.Key
+			sizes[i] = itemSize(item.Update.Key) + itemSize(item.Update.ExpressionAttributeValues)
+		case item.ConditionCheck != nil:
+			key = item.ConditionCheck.Key
+			sizes[i] = itemSize(item.ConditionCheck.Key) + itemSize(item.ConditionCheck.ExpressionAttributeValues)
+		}
+
+		k := c.schema.getKey(key)
+		if prev, ok := seen[k]; ok {
+			return fmt.Errorf("%w: %s and %s are both on the item with PK %q and SK %q, but a transaction can only have one operation on each item",
+				ErrInvalidTransaction, tx[prev].describe(prev), tx[i].describe(i), k.PK, k.SK)
+		}
+		seen[k] = i
+
+		total += sizes[i]
+		if sizes[i] > sizes[largest] {
+			largest = i
+		}
+	}
+
+	if total > MaxTransactionSize {
+		return fmt.Errorf("%w: transaction is %d bytes, which is more than the limit of %d bytes. The largest operation is %s at %d bytes",
+			ErrInvalidTransaction, total, MaxTransactionSize, tx[largest].describe(largest), sizes[largest])
+	}
+	return nil
+}
+
+// describe returns a description of the operation at index i of a transaction, such as 'operation 2 (Put)'.
+func (t TransactWriteItem) describe(i int) string {
+	var ops []string
+	if t.Put != nil {
+		ops = append(ops, "Put")
+	}
+	if t.Delete != nil {
+		ops = append(ops, "Delete")
+	}
+	if t.Update != nil {
+		ops = append(ops, "Update")
+	}
+	if t.ConditionCheck != nil {
+		ops = append(ops, "ConditionCheck")
+	}
+	if len(ops) == 0 {
+		return fmt.Sprintf("operation %d", i)
+	}
+	return fmt.Sprintf("operation %d (%s)", i, strings.Join(ops, ", "))
+}
+
+// TransactionCancelCode is the reason DynamoDB gave for cancelling an operation in a transaction.
+type TransactionCancelCode string
+
+const (
+	// CancelConditionalCheckFailed means the condition on the operation wasn't met.
+	CancelConditionalCheckFailed TransactionCancelCode = "ConditionalCheckFailed"
+	// CancelTransactionConflict means the item is being modified by another transaction.
+	CancelTransactionConflict TransactionCancelCode = "TransactionConflict"
+	// CancelThrottlingError means the request rate for the table was too high.
+	CancelThrottlingError TransactionCancelCode = "ThrottlingError"
+	// CancelValidationError means the operation was invalid, for example because
+	// the item was too large.
+	CancelValidationError TransactionCancelCode = "ValidationError"
+)
+
+// TransactionError is returned by TransactWriteItems if DynamoDB cancels the transaction.
+// It lists the operations which caused the transaction to be cancelled.
+//
+// errors.Is(err, ErrVersionConflict) is true if any Versioned items were modified concurrently,
+// and errors.Is(err, ErrConditionFailed) is true if any other operations failed
+// because their condition wasn't met.
+type TransactionError struct {
+	// Cancellations are the operations which caused the transaction to be cancelled,
+	// in the order they appear in the TransactWriteItems payload.
+	Cancellations []TransactionCancellation
+	// Err is the underlying *types.TransactionCanceledException.
+	Err error
+}
+
+// TransactionCancellation is the reason an operation caused a transaction to be cancelled.
+type TransactionCancellation struct {
+	// Index is the position of the operation in the []TransactWriteItem passed to
+	// TransactWriteItems. Transactions built with NewTransaction() group operations by type,
+	// with puts first, followed by deletes, updates and condition checks, so Index doesn't
+	// match the order that Put(), Delete(), Update() and ConditionCheck() were called in.
+	// Use Item or Keyer to identify the operation instead.
+	Index int
+	// Item is the operation which was cancelled.
+	Item TransactWriteItem
+	// Keyer is the item which was being written for Put and Delete operations,
+	// and nil otherwise. Update and ConditionCheck operations are identified by
+	// the key in Item.
+	Keyer Keyer
+	// Code is the reason the operation was cancelled. Codes other than the
+	// Cancel constants, such as ProvisionedThroughputExceeded, are passed through as-is.
+	Code TransactionCancelCode
+	// Message is the message returned by DynamoDB.
+	Message string
+	// VersionConflict is true if the operation was on a Versioned item which has been
+	// modified concurrently. It is only set if the version check was the operation's only condition,
+	// as otherwise DynamoDB doesn't report which of the conditions failed.
+	VersionConflict bool
+}
+
+func (c TransactionCancellation) String() string {
+	if c.Message == "" {
+		return fmt.Sprintf("operation %d: %s", c.Index, c.Code)
+	}
+	return fmt.Sprintf("operation %d: %s: %s", c.Index, c.Code, c.Message)
+}
+
+func (e *TransactionError) Error() string {
+	if len(e.Cancellations) == 0 {
+		return fmt.Sprintf("transaction cancelled: %s", e.Err)
+	}
+	reasons := make([]string, len(e.Cancellations))
+	for i, c := range e.Cancellations {
+		reasons[i] = c.String()
+	}
+	return "transaction cancelled: " + strings.Join(reasons, "; ")
+}
+
+// Unwrap returns the underlying *types.TransactionCanceledException.
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true for ErrVersionConflict if any versioned items were modified concurrently,
+// and true for ErrConditionFailed if the condition of any other operation wasn't met.
+func (e *TransactionError) Is(target error) bool {
+	if target != ErrConditionFailed && target != ErrVersionConflict {
+		return false
+	}
+	for _, c := range e.Cancellations {
+		if c.Code == CancelConditionalCheckFailed && c.VersionConflict == (target == ErrVersionConflict) {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictOnly returns true if all of the operations were cancelled
+// because of conflicts with other transactions.
+func (e *TransactionError) conflictOnly() bool {
+	if len(e.Cancellations) == 0 {
+		return false
+	}
+	for _, c := range e.Cancellations {
+		if c.Code != CancelTransactionConflict {
+			return false
+		}
+	}
+	return true
+}
+
+// transactionError converts a cancelled transaction into a *TransactionError.
+// Other errors are returned unchanged.
+func transactionError(err error, tx []TransactWriteItem, versions []*itemVersion) error {
+	var tce *types.TransactionCanceledException
+	if !errors.As(err, &tce) {
+		return err
+	}
+	te := &TransactionError{Err: err}
+	for i, reason := range tce.CancellationReasons {
+		code := TransactionCancelCode(aws.ToString(reason.Code))
+		if code == "" || code == "None" {
+			continue
+		}
+		c := TransactionCancellation{
+			Index:   i,
+			Code:    code,
+			Message: aws.ToString(reason.Message),
+		}
+		if i < len(versions) && versions[i] != nil && i < len(tx) {
+			c.VersionConflict = code == CancelConditionalCheckFailed && !tx[i].Opts.HasCondition()
+		}
+		if i < len(tx) {
+			c.Item = tx[i]
+			c.Keyer = tx[i].Put
+			if c.Keyer == nil {
+				c.Keyer = tx[i].Delete
+			}
+		}
+		te.Cancellations = append(te.Cancellations, c)
+	}
+	return te
 }
@@ -5,51 +5,130 @@ import (
 	"sync"
 )
 
-func (c *Client) NewTransaction() Transaction {
+// NewTransaction creates a new transaction. Options such as RetryOnConflict()
+// are used when the transaction is executed.
+func (c *Client) NewTransaction(opts ...func(*TransactionOpts)) Transaction {
 	return &DBTransaction{
 		client: c,
+		opts:   opts,
 	}
 }
 
 // DBTransaction writes pending items to slices in memory.
 // It is goroutine-safe.
+//
+// The operations are sent to DynamoDB grouped by type: puts first, followed by
+// deletes, updates and condition checks. TransactionCancellation.Index refers to
+// this order, rather than the order that the operations were added in.
 type DBTransaction struct {
-	client Storage
+	client *Client
+	opts   []func(*TransactionOpts)
 	// mu is a mutex to prevent concurrent writes to the
-	// putItems and deleteItems slices.
+	// putItems, deleteItems, updateItems and conditionChecks slices.
 	mu          sync.Mutex
 	putItems    []Keyer
 	deleteItems []Keyer
+	updateItems []UpdateItem
+	// conditionChecks are the keys of items which are checked without being written.
+	conditionChecks []GetKey
+	// putOpts, deleteOpts, updateOpts and checkOpts hold the write options
+	// for the entry at the same index in the slices above.
+	putOpts    []WriteOpts
+	deleteOpts []WriteOpts
+	updateOpts []WriteOpts
+	checkOpts  []WriteOpts
 }
 
-func (t *DBTransaction) Put(item Keyer) {
+func (t *DBTransaction) Put(item Keyer, opts ...func(*WriteOpts)) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.putItems = append(t.putItems, item)
+	t.putOpts = append(t.putOpts, writeOpts(opts))
 }
 
-func (t *DBTransaction) Delete(item Keyer) {
+func (t *DBTransaction) Delete(item Keyer, opts ...func(*WriteOpts)) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.deleteItems = append(t.deleteItems, item)
+	t.deleteOpts = append(t.deleteOpts, writeOpts(opts))
+}
+
+func (t *DBTransaction) Update(key GetKey, ub UpdateBuilder, opts ...func(*WriteOpts)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.updateItems = append(t.updateItems, UpdateItem{Key: key, Update: ub})
+	t.updateOpts = append(t.updateOpts, writeOpts(opts))
+}
+
+func (t *DBTransaction) ConditionCheck(key GetKey, opts ...func(*WriteOpts)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.conditionChecks = append(t.conditionChecks, key)
+	t.checkOpts = append(t.checkOpts, writeOpts(opts))
+}
+
+// Validate checks the transaction against the limits of the TransactWriteItems API,
+// without calling the API. Execute also validates the transaction before calling the API.
+func (t *DBTransaction) Validate() error {
+	items := t.buildTransactWriteItemsPayload()
+	return t.client.ValidateTransactWriteItems(items)
 }
 
 func (t *DBTransaction) Execute(ctx context.Context) error {
 	items := t.buildTransactWriteItemsPayload()
-	return t.client.TransactWriteItems(ctx, items)
+	return t.client.TransactWriteItems(ctx, items, t.opts...)
 }
 
+// buildTransactWriteItemsPayload returns the pending operations. The mutex is held
+// while reading them, so that each entry is paired with the options it was added with.
 func (t *DBTransaction) buildTransactWriteItemsPayload() []TransactWriteItem {
-	items := make([]TransactWriteItem, len(t.putItems)+len(t.deleteItems))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	items := make([]TransactWriteItem, 0, len(t.putItems)+len(t.deleteItems)+len(t.updateItems)+len(t.conditionChecks))
 	for i := range t.putItems {
-		items[i] = TransactWriteItem{
-			Put: t.putItems[i],
-		}
+		items = append(items, TransactWriteItem{
+			Put:  t.putItems[i],
+			Opts: optsAt(t.putOpts, i),
+		})
 	}
 	for i := range t.deleteItems {
-		items[len(t.putItems)+i] = TransactWriteItem{
+		items = append(items, TransactWriteItem{
 			Delete: t.deleteItems[i],
-		}
+			Opts:   optsAt(t.deleteOpts, i),
+		})
+	}
+	// copy updates and condition checks, as the payload is used after the mutex is released.
+	for i := range t.updateItems {
+		update := t.updateItems[i]
+		items = append(items, TransactWriteItem{
+			Update: &update,
+			Opts:   optsAt(t.updateOpts, i),
+		})
+	}
+	for i := range t.conditionChecks {
+		key := t.conditionChecks[i]
+		items = append(items, TransactWriteItem{
+			ConditionCheck: &key,
+			Opts:           optsAt(t.checkOpts, i),
+		})
 	}
 	return items
 }
+
+// writeOpts applies the write options.
+func writeOpts(opts []func(*WriteOpts)) WriteOpts {
+	wo := WriteOpts{}
+	for _, o := range opts {
+		o(&wo)
+	}
+	return wo
+}
+
+// optsAt returns the write options at index i, or empty options if none were set.
+func optsAt(opts []WriteOpts, i int) WriteOpts {
+	if i < len(opts) {
+		return opts[i]
+	}
+	return WriteOpts{}
+}
@@ -1,8 +1,13 @@
 package ddb
 
 import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -88,3 +93,156 @@ func TestTransaction_buildTransactWriteItemsPayload(t *testing.T) {
 		})
 	}
 }
+
+type largeItem struct {
+	ID   string
+	Data string
+}
+
+func (l largeItem) DDBKeys() (Keys, error) {
+	return Keys{PK: "LARGE", SK: l.ID}, nil
+}
+
+func TestTransactionConcurrentPayload(t *testing.T) {
+	tx := &DBTransaction{}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		id := strconv.Itoa(i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			tx.Put(fakeThing{ID: id}, Condition(id, nil, nil))
+		}()
+		go func() {
+			defer wg.Done()
+			// each entry must be paired with the options it was added with.
+			for _, item := range tx.buildTransactWriteItemsPayload() {
+				assert.Equal(t, item.Put.(fakeThing).ID, item.Opts.Condition.Expression)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, tx.buildTransactWriteItemsPayload(), 50)
+}
+
+func TestTransactionValidate(t *testing.T) {
+	f := &fakeDynamoDB{}
+	c := newFakeClient(t, f)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		build   func(tx Transaction)
+		wantErr string
+	}{
+		{
+			name:    "empty",
+			build:   func(tx Transaction) {},
+			wantErr: "invalid transaction: transaction has no operations",
+		},
+		{
+			name: "too many operations",
+			build: func(tx Transaction) {
+				for i := 0; i < 101; i++ {
+					tx.Put(fakeThing{ID: strconv.Itoa(i)})
+				}
+			},
+			wantErr: "invalid transaction: transaction has 101 operations, which is more than the limit of 100",
+		},
+		{
+			name: "duplicate keys",
+			build: func(tx Transaction) {
+				tx.Put(fakeThing{ID: "1"})
+				tx.Put(fakeThing{ID: "2"})
+				tx.Delete(fakeThing{ID: "2"})
+			},
+			wantErr: `invalid transaction: operation 1 (Put) and operation 2 (Delete) are both on the item with PK "THING" and SK "2", but a transaction can only have one operation on each item`,
+		},
+		{
+			name: "condition check on a written item",
+			build: func(tx Transaction) {
+				tx.Put(fakeThing{ID: "1"})
+				tx.ConditionCheck(GetKey{PK: "THING", SK: "1"}, IfExists())
+			},
+			wantErr: `invalid transaction: operation 0 (Put) and operation 1 (ConditionCheck) are both on the item with PK "THING" and SK "1", but a transaction can only have one operation on each item`,
+		},
+		{
+			name: "too large",
+			build: func(tx Transaction) {
+				for i := 0; i < 5; i++ {
+					tx.Put(largeItem{ID: strconv.Itoa(i), Data: strings.Repeat("a", 1024*1024)})
+				}
+			},
+			wantErr: "invalid transaction: transaction is 5242965 bytes, which is more than the limit of 4194304 bytes. The largest operation is operation 0 (Put) at 1048593 bytes",
+		},
+		{
+			name: "ok",
+			build: func(tx Transaction) {
+				tx.Put(fakeThing{ID: "1"})
+				tx.Delete(fakeThing{ID: "2"})
+				tx.ConditionCheck(GetKey{PK: "THING", SK: "3"}, IfExists())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := c.NewTransaction()
+			tt.build(tx)
+			err := tx.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidTransaction)
+			assert.EqualError(t, err, tt.wantErr)
+
+			// invalid transactions aren't sent to DynamoDB.
+			err = tx.Execute(ctx)
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Empty(t, f.transactWriteItems)
+		})
+	}
+}
+
+func TestValidateTransactWriteItems(t *testing.T) {
+	c := newFakeClient(t, &fakeDynamoDB{})
+
+	tests := []struct {
+		name    string
+		give    []TransactWriteItem
+		wantErr string
+	}{
+		{
+			name:    "no operation",
+			give:    []TransactWriteItem{{Put: fakeThing{ID: "1"}}, {}},
+			wantErr: "invalid transaction: operation 1 has no operation defined",
+		},
+		{
+			name:    "multiple operations",
+			give:    []TransactWriteItem{{Put: fakeThing{ID: "1"}, Delete: fakeThing{ID: "1"}}},
+			wantErr: "invalid transaction: operation 0 (Put, Delete) has multiple operations defined, only one of Put, Delete, Update or ConditionCheck may be set",
+		},
+		{
+			name:    "return values",
+			give:    []TransactWriteItem{{Put: fakeThing{ID: "1"}, Opts: WriteOpts{ReturnValues: types.ReturnValueAllOld}}},
+			wantErr: "invalid transaction: operation 0 (Put) uses ReturnNew or ReturnOld, which are not supported in transactions",
+		},
+		{
+			name:    "no update",
+			give:    []TransactWriteItem{{Update: &UpdateItem{Key: GetKey{PK: "THING", SK: "1"}}}},
+			wantErr: "invalid transaction: operation 0 (Update) has no update defined",
+		},
+		{
+			name:    "condition check without a condition",
+			give:    []TransactWriteItem{{ConditionCheck: &GetKey{PK: "THING", SK: "1"}}},
+			wantErr: "invalid transaction: operation 0 (ConditionCheck) has no condition defined",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.ValidateTransactWriteItems(tt.give)
+			assert.ErrorIs(t, err, ErrInvalidTransaction)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
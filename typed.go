@@ -0,0 +1,181 @@
+package ddb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// GetAs gets the item with the given key, returning it as a T.
+// T is usually a struct implementing Keyer, or a struct with keys declared using
+// struct tags (see Tagged). ErrNoItems is returned if the item doesn't exist.
+//
+//	apple, err := ddb.GetAs[Apple](ctx, db, ddb.GetKey{PK: ..., SK: ...})
+func GetAs[T any](ctx context.Context, s Storage, key GetKey, opts ...func(*GetOpts)) (T, error) {
+	v, target := newTarget[T]()
+	_, err := s.Get(ctx, key, keyerFor(target), opts...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return *v, nil
+}
+
+// QueryAs queries a single page of items, returning them as a []T along with
+// the token of the next page, which is empty if there are no more pages.
+//
+// If qb has a `ddb:"result"` field, it must be a []T, and the results are read
+// from it after calling Query. This allows QueryAs to be used with mocked queries.
+// Otherwise, qb only needs to build the query, so it can be a QueryExpression:
+//
+//	apples, next, err := ddb.QueryAs[Apple](ctx, db, ddb.Q().PK("APPLE"))
+func QueryAs[T any](ctx context.Context, s Storage, qb QueryBuilder, opts ...func(*QueryOpts)) ([]T, string, error) {
+	out, get, err := typedResult[T](qb)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := s.Query(ctx, out, opts...)
+	if err != nil {
+		return nil, "", err
+	}
+	var next string
+	if res != nil {
+		next = res.NextPage
+	}
+	return get(), next, nil
+}
+
+// AllAs reads every page of a query, returning the items as a []T.
+// qb is handled in the same way as QueryAs.
+func AllAs[T any](ctx context.Context, s Storage, qb QueryBuilder, opts ...func(*QueryOpts)) ([]T, error) {
+	out, get, err := typedResult[T](qb)
+	if err != nil {
+		return nil, err
+	}
+	err = s.All(ctx, out, opts...)
+	// All sets the results read so far if a read limit is exceeded, so return them with the error.
+	return get(), err
+}
+
+// typedQuery adds a []T result field to a QueryBuilder which doesn't have one.
+type typedQuery[T any] struct {
+	qb     QueryBuilder
+	Result []T `ddb:"result"`
+}
+
+func (q *typedQuery[T]) BuildQuery() (*dynamodb.QueryInput, error) {
+	return q.qb.BuildQuery()
+}
+
+// typedResult returns the QueryBuilder to query with, and a function returning its results.
+func typedResult[T any](qb QueryBuilder) (QueryBuilder, func() []T, error) {
+	field, err := findResultsTag(qb)
+	if err != nil {
+		return nil, nil, err
+	}
+	if field == nil {
+		if _, ok := qb.(QueryOutputUnmarshaler); ok {
+			return nil, nil, fmt.Errorf("%T implements UnmarshalQueryOutput, so its results can't be returned as a []%s", qb, reflect.TypeOf((*T)(nil)).Elem())
+		}
+		tq := &typedQuery[T]{qb: qb}
+		return tq, func() []T { return tq.Result }, nil
+	}
+	results, ok := field.Interface().(*[]T)
+	if !ok {
+		return nil, nil, fmt.Errorf("the `ddb:\"result\"` field of %T is a %s, not a []%s", qb, field.Elem().Type(), reflect.TypeOf((*T)(nil)).Elem())
+	}
+	return qb, func() []T { return *results }, nil
+}
+
+// newTarget returns a pointer to a new T, along with the value to unmarshal into.
+// If T is itself a pointer, the value it points to is allocated.
+func newTarget[T any]() (*T, interface{}) {
+	v := new(T)
+	t := reflect.TypeOf(v).Elem()
+	if t.Kind() == reflect.Pointer {
+		elem := reflect.New(t.Elem())
+		reflect.ValueOf(v).Elem().Set(elem)
+		return v, elem.Interface()
+	}
+	return v, v
+}
+
+// keyerFor returns the item as a Keyer, wrapping it with Tagged if it doesn't implement Keyer.
+func keyerFor(item interface{}) Keyer {
+	if k, ok := item.(Keyer); ok {
+		return k
+	}
+	return Tagged(item)
+}
+
+// Repository provides typed access to items of type T.
+// It wraps a Storage, such as a *Client or a ddbmock.Client.
+//
+//	apples := ddb.NewRepository[Apple](db)
+//	apple, err := apples.Get(ctx, ddb.GetKey{PK: ..., SK: ...})
+type Repository[T Keyer] struct {
+	s Storage
+}
+
+// NewRepository creates a Repository for items of type T.
+func NewRepository[T Keyer](s Storage) *Repository[T] {
+	return &Repository[T]{s: s}
+}
+
+// Storage returns the underlying Storage.
+func (r *Repository[T]) Storage() Storage {
+	return r.s
+}
+
+// Get gets the item with the given key. ErrNoItems is returned if it doesn't exist.
+func (r *Repository[T]) Get(ctx context.Context, key GetKey, opts ...func(*GetOpts)) (T, error) {
+	return GetAs[T](ctx, r.s, key, opts...)
+}
+
+// GetBatch gets the items with the given keys, in the same order as the keys.
+// If any keys did not match an item, the items which were found are returned along with a *MissingKeysError.
+func (r *Repository[T]) GetBatch(ctx context.Context, keys []GetKey, opts ...func(*GetOpts)) ([]T, error) {
+	var items []T
+	_, err := r.s.GetBatch(ctx, keys, &items, opts...)
+	return items, err
+}
+
+// Query queries a single page of items. See QueryAs.
+func (r *Repository[T]) Query(ctx context.Context, qb QueryBuilder, opts ...func(*QueryOpts)) ([]T, string, error) {
+	return QueryAs[T](ctx, r.s, qb, opts...)
+}
+
+// All reads every page of a query. See AllAs.
+func (r *Repository[T]) All(ctx context.Context, qb QueryBuilder, opts ...func(*QueryOpts)) ([]T, error) {
+	return AllAs[T](ctx, r.s, qb, opts...)
+}
+
+// Put creates or updates an item.
+func (r *Repository[T]) Put(ctx context.Context, item T, opts ...func(*WriteOpts)) error {
+	return r.s.Put(ctx, item, opts...)
+}
+
+// PutBatch creates or updates items in batches.
+func (r *Repository[T]) PutBatch(ctx context.Context, items ...T) error {
+	return r.s.PutBatch(ctx, keyers(items)...)
+}
+
+// Delete deletes an item.
+func (r *Repository[T]) Delete(ctx context.Context, item T, opts ...func(*WriteOpts)) error {
+	return r.s.Delete(ctx, item, opts...)
+}
+
+// DeleteBatch deletes items in batches.
+func (r *Repository[T]) DeleteBatch(ctx context.Context, items ...T) error {
+	return r.s.DeleteBatch(ctx, keyers(items)...)
+}
+
+func keyers[T Keyer](items []T) []Keyer {
+	out := make([]Keyer, len(items))
+	for i, item := range items {
+		out[i] = item
+	}
+	return out
+}
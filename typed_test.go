@@ -0,0 +1,81 @@
+package ddb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+type typedThings struct {
+	Result []fakeThing `ddb:"result"`
+}
+
+func (q *typedThings) BuildQuery() (*dynamodb.QueryInput, error) {
+	return &dynamodb.QueryInput{}, nil
+}
+
+func TestTypedQuery(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		give    QueryBuilder
+		wantErr bool
+	}{
+		{
+			name: "result field",
+			give: &typedThings{},
+		},
+		{
+			name: "no result field",
+			give: &staticQuery{in: &dynamodb.QueryInput{}},
+		},
+		{
+			name: "query expression",
+			give: Q().PK("THING"),
+		},
+		{
+			name:    "custom unmarshalling",
+			give:    &unmarshalOnlyQuery{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeDynamoDB{pages: fakeQueryPages("1", "2")}
+			c := &Client{client: f, table: "test", tokenizer: &JSONTokenizer{}, schema: DefaultTableSchema()}
+
+			got, next, err := QueryAs[fakeThing](ctx, c, tt.give)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QueryAs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, []fakeThing{{ID: "1"}}, got)
+			assert.NotEmpty(t, next)
+
+			all, err := AllAs[fakeThing](ctx, c, tt.give, Page(next))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, []fakeThing{{ID: "2"}}, all)
+		})
+	}
+}
+
+func TestTypedQueryWrongResultType(t *testing.T) {
+	c := &Client{client: &fakeDynamoDB{}, table: "test", tokenizer: &JSONTokenizer{}, schema: DefaultTableSchema()}
+	_, _, err := QueryAs[string](context.Background(), c, &typedThings{})
+	assert.Error(t, err)
+}
+
+func TestNewTarget(t *testing.T) {
+	v, target := newTarget[fakeThing]()
+	assert.Same(t, v, target)
+
+	p, target := newTarget[*fakeThing]()
+	assert.NotNil(t, *p)
+	assert.Same(t, *p, target)
+}
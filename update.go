@@ -0,0 +1,287 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// UpdateBuilders build update inputs for partial updates to an item.
+// The inputs are passed to the UpdateItem DynamoDB API.
+//
+// The Update type implements UpdateBuilder and is the easiest way to
+// write an update. You can implement UpdateBuilder yourself if you need
+// full control over the update expression.
+type UpdateBuilder interface {
+	BuildUpdate() (*dynamodb.UpdateItemInput, error)
+}
+
+// Update builds an update expression from SET, REMOVE, ADD and DELETE clauses.
+// Placeholders are generated for all attribute names and values, so reserved
+// words can be used as attribute names.
+//
+// Attribute paths are separated by dots for nested attributes,
+// and list elements are referenced with square brackets, for example 'Address.Lines[0]'.
+//
+//	u := ddb.NewUpdate().
+//		Set("Status", "ACTIVE").
+//		Add("LoginCount", 1).
+//		Remove("LockedAt")
+type Update struct {
+	set    []string
+	remove []string
+	add    []string
+	delete []string
+	ph     *placeholders
+	// err is the first error encountered while building the update.
+	// It is returned by BuildUpdate().
+	err error
+}
+
+// NewUpdate creates a new empty Update.
+func NewUpdate() *Update {
+	return &Update{ph: newPlaceholders("u")}
+}
+
+var _ UpdateBuilder = &Update{}
+
+// Set sets an attribute to a value, replacing any existing value.
+func (u *Update) Set(path string, value interface{}) *Update {
+	p, v, ok := u.pathValue(path, value)
+	if ok {
+		u.set = append(u.set, p+" = "+v)
+	}
+	return u
+}
+
+// SetIfNotExists sets an attribute to a value only if the attribute doesn't already exist.
+func (u *Update) SetIfNotExists(path string, value interface{}) *Update {
+	p, v, ok := u.pathValue(path, value)
+	if ok {
+		u.set = append(u.set, fmt.Sprintf("%s = if_not_exists(%s, %s)", p, p, v))
+	}
+	return u
+}
+
+// Append adds values to the end of a list attribute.
+// If the attribute doesn't exist, it is created.
+func (u *Update) Append(path string, values ...interface{}) *Update {
+	p, v, ok := u.pathValue(path, values)
+	if !ok {
+		return u
+	}
+	empty, err := u.ph.value(&types.AttributeValueMemberL{Value: []types.AttributeValue{}})
+	if err != nil {
+		u.setErr(err)
+		return u
+	}
+	u.set = append(u.set, fmt.Sprintf("%s = list_append(if_not_exists(%s, %s), %s)", p, p, empty, v))
+	return u
+}
+
+// Add adds a number to a number attribute, or adds elements to a set attribute.
+// If the attribute doesn't exist, it is created. Use Add to increment counters:
+//
+//	ddb.NewUpdate().Add("Views", 1)
+func (u *Update) Add(path string, value interface{}) *Update {
+	p, v, ok := u.pathValue(path, value)
+	if ok {
+		u.add = append(u.add, p+" "+v)
+	}
+	return u
+}
+
+// Delete removes elements from a set attribute.
+// The value must be a set of the same type, such as *types.AttributeValueMemberSS.
+func (u *Update) Delete(path string, value interface{}) *Update {
+	p, v, ok := u.pathValue(path, value)
+	if ok {
+		u.delete = append(u.delete, p+" "+v)
+	}
+	return u
+}
+
+// Remove removes an attribute from the item.
+func (u *Update) Remove(path string) *Update {
+	p, err := u.ph.path(path)
+	if err != nil {
+		u.setErr(err)
+		return u
+	}
+	u.remove = append(u.remove, p)
+	return u
+}
+
+// pathValue returns placeholders for an attribute path and a value.
+// If an error occurs, it is recorded on the update and ok is false.
+func (u *Update) pathValue(path string, value interface{}) (p string, v string, ok bool) {
+	p, err := u.ph.path(path)
+	if err != nil {
+		u.setErr(err)
+		return "", "", false
+	}
+	v, err = u.ph.value(value)
+	if err != nil {
+		u.setErr(fmt.Errorf("marshalling value for %s: %w", path, err))
+		return "", "", false
+	}
+	return p, v, true
+}
+
+func (u *Update) setErr(err error) {
+	if u.err == nil {
+		u.err = err
+	}
+}
+
+// Expression returns the update expression.
+func (u *Update) Expression() string {
+	var clauses []string
+	if len(u.set) > 0 {
+		clauses = append(clauses, "SET "+strings.Join(u.set, ", "))
+	}
+	if len(u.remove) > 0 {
+		clauses = append(clauses, "REMOVE "+strings.Join(u.remove, ", "))
+	}
+	if len(u.add) > 0 {
+		clauses = append(clauses, "ADD "+strings.Join(u.add, ", "))
+	}
+	if len(u.delete) > 0 {
+		clauses = append(clauses, "DELETE "+strings.Join(u.delete, ", "))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// BuildUpdate builds the UpdateItem input.
+// An error is returned if any of the clauses were invalid, or if the update is empty.
+func (u *Update) BuildUpdate() (*dynamodb.UpdateItemInput, error) {
+	if u.err != nil {
+		return nil, u.err
+	}
+	expr := u.Expression()
+	if expr == "" {
+		return nil, errors.New("update must contain at least one clause")
+	}
+
+	in := &dynamodb.UpdateItemInput{
+		UpdateExpression: &expr,
+	}
+	if len(u.ph.names) > 0 {
+		in.ExpressionAttributeNames = u.ph.names
+	}
+	if len(u.ph.values) > 0 {
+		in.ExpressionAttributeValues = u.ph.values
+	}
+	return in, nil
+}
+
+type UpdateItemResult struct {
+	// RawOutput is the DynamoDB API response. Usually you won't need this,
+	// as returned values are parsed onto the item provided to ReturnNew() or ReturnOld().
+	RawOutput *dynamodb.UpdateItemOutput
+}
+
+// ReturnNew unmarshals the item as it appears after the update into 'item'.
+// The item must be passed by reference. ReturnNew is only supported by Update.
+func ReturnNew(item Keyer) func(*WriteOpts) {
+	return func(wo *WriteOpts) {
+		wo.ReturnValues = types.ReturnValueAllNew
+		wo.ReturnItem = item
+	}
+}
+
+// ReturnOld unmarshals the item as it appeared before the write into 'item'.
+// The item must be passed by reference. If there was no previous item,
+// 'item' is not modified.
+func ReturnOld(item Keyer) func(*WriteOpts) {
+	return func(wo *WriteOpts) {
+		wo.ReturnValues = types.ReturnValueAllOld
+		wo.ReturnItem = item
+	}
+}
+
+// checkOldReturnValues returns an error if the write requests return values other than
+// the old item, which PutItem and DeleteItem don't support.
+func (wo WriteOpts) checkOldReturnValues(op string) error {
+	switch wo.ReturnValues {
+	case "", types.ReturnValueNone, types.ReturnValueAllOld:
+		return nil
+	}
+	return fmt.Errorf("%s only supports ReturnOld, but %s return values were requested: ReturnNew is only supported by Update", op, wo.ReturnValues)
+}
+
+// unmarshalReturnValues unmarshals the attributes returned by a write
+// onto the item provided to ReturnNew() or ReturnOld().
+func (wo WriteOpts) unmarshalReturnValues(attrs map[string]types.AttributeValue, schema TableSchema) error {
+	if wo.ReturnItem == nil || len(attrs) == 0 {
+		return nil
+	}
+	return unmarshalItem(attrs, wo.ReturnItem, schema)
+}
+
+// buildUpdateItemInput builds the UpdateItem input for an update to the item with the given key.
+// It is shared between Update and TransactWriteItems.
+func (c *Client) buildUpdateItemInput(key GetKey, ub UpdateBuilder, wo WriteOpts) (*dynamodb.UpdateItemInput, error) {
+	in, err := ub.BuildUpdate()
+	if err != nil {
+		return nil, err
+	}
+	if in.UpdateExpression == nil || *in.UpdateExpression == "" {
+		return nil, errors.New("update expression must not be empty")
+	}
+
+	// update builders don't know which table the client uses or
+	// which item is being updated, so set these here.
+	in.TableName = &c.table
+	in.Key = c.schema.key(key)
+
+	if condition := wo.buildCondition(c.schema); condition != nil {
+		if in.ConditionExpression != nil {
+			condition = condition.and(ConditionExpression{Expression: *in.ConditionExpression})
+		}
+		in.ConditionExpression = &condition.Expression
+		err = mergeExpressionAttributes(&in.ExpressionAttributeNames, &in.ExpressionAttributeValues, condition.Names, condition.Values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if wo.ReturnValues != "" {
+		in.ReturnValues = wo.ReturnValues
+	}
+	return in, nil
+}
+
+// Update calls UpdateItem to make a partial update to the item with the given key.
+// If the item doesn't exist, it is created.
+//
+//	db.Update(ctx, ddb.GetKey{PK: "USER#1", SK: "USER#1"}, ddb.NewUpdate().Add("LoginCount", 1))
+//
+// Update can be made conditional by providing options such as IfExists() or Condition().
+// If the condition is not met, ErrConditionFailed is returned.
+//
+// To read the item back after the update, provide ReturnNew(&item).
+func (c *Client) Update(ctx context.Context, key GetKey, ub UpdateBuilder, opts ...func(*WriteOpts)) (*UpdateItemResult, error) {
+	wo := WriteOpts{}
+	for _, o := range opts {
+		o(&wo)
+	}
+
+	in, err := c.buildUpdateItemInput(key, ub, wo)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.client.UpdateItem(ctx, in)
+	res := &UpdateItemResult{RawOutput: out}
+	if err != nil {
+		return res, conditionError(err, nil, wo)
+	}
+
+	err = wo.unmarshalReturnValues(out.Attributes, c.schema)
+	return res, err
+}
@@ -0,0 +1,120 @@
+package ddb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		give       *Update
+		wantExpr   string
+		wantNames  map[string]string
+		wantValues map[string]types.AttributeValue
+		wantErr    bool
+	}{
+		{
+			name:      "set",
+			give:      NewUpdate().Set("Status", "ACTIVE"),
+			wantExpr:  "SET #u0 = :u0",
+			wantNames: map[string]string{"#u0": "Status"},
+			wantValues: map[string]types.AttributeValue{
+				":u0": &types.AttributeValueMemberS{Value: "ACTIVE"},
+			},
+		},
+		{
+			name:      "all clauses",
+			give:      NewUpdate().Set("A", 1).Remove("B").Add("C", 2).Delete("D", &types.AttributeValueMemberSS{Value: []string{"x"}}),
+			wantExpr:  "SET #u0 = :u0 REMOVE #u1 ADD #u2 :u1 DELETE #u3 :u2",
+			wantNames: map[string]string{"#u0": "A", "#u1": "B", "#u2": "C", "#u3": "D"},
+			wantValues: map[string]types.AttributeValue{
+				":u0": &types.AttributeValueMemberN{Value: "1"},
+				":u1": &types.AttributeValueMemberN{Value: "2"},
+				":u2": &types.AttributeValueMemberSS{Value: []string{"x"}},
+			},
+		},
+		{
+			name:      "nested path reuses names",
+			give:      NewUpdate().Set("Address.Lines[0]", "1 Main St").Remove("Address.Lines[1]"),
+			wantExpr:  "SET #u0.#u1[0] = :u0 REMOVE #u0.#u1[1]",
+			wantNames: map[string]string{"#u0": "Address", "#u1": "Lines"},
+			wantValues: map[string]types.AttributeValue{
+				":u0": &types.AttributeValueMemberS{Value: "1 Main St"},
+			},
+		},
+		{
+			name:      "set if not exists",
+			give:      NewUpdate().SetIfNotExists("CreatedAt", "today"),
+			wantExpr:  "SET #u0 = if_not_exists(#u0, :u0)",
+			wantNames: map[string]string{"#u0": "CreatedAt"},
+			wantValues: map[string]types.AttributeValue{
+				":u0": &types.AttributeValueMemberS{Value: "today"},
+			},
+		},
+		{
+			name:    "empty",
+			give:    NewUpdate(),
+			wantErr: true,
+		},
+		{
+			name:    "invalid path",
+			give:    NewUpdate().Set("Address..Line", "x"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid index",
+			give:    NewUpdate().Remove("Lines[a]"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.give.BuildUpdate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantExpr, *got.UpdateExpression)
+			assert.Equal(t, tt.wantNames, got.ExpressionAttributeNames)
+			assert.Equal(t, tt.wantValues, got.ExpressionAttributeValues)
+		})
+	}
+}
+
+func TestBuildUpdateItemInput(t *testing.T) {
+	c := &Client{table: "example", schema: DefaultTableSchema()}
+	in, err := c.buildUpdateItemInput(GetKey{PK: "A", SK: "B"}, NewUpdate().Set("Status", "ACTIVE"), WriteOpts{IfExists: true, ReturnValues: types.ReturnValueAllNew})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "example", *in.TableName)
+	assert.Equal(t, map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "A"},
+		"SK": &types.AttributeValueMemberS{Value: "B"},
+	}, in.Key)
+	assert.Equal(t, "attribute_exists(#ddbpk)", *in.ConditionExpression)
+	assert.Equal(t, map[string]string{"#ddbpk": "PK", "#u0": "Status"}, in.ExpressionAttributeNames)
+	assert.Equal(t, types.ReturnValueAllNew, in.ReturnValues)
+}
+
+func TestReturnNewOnlySupportedByUpdate(t *testing.T) {
+	// the fake doesn't implement PutItem or DeleteItem, so the writes must fail before calling the API.
+	c := newFakeClient(t, &fakeDynamoDB{})
+	ctx := context.Background()
+
+	var got fakeThing
+	err := c.Put(ctx, fakeThing{ID: "1"}, ReturnNew(&got))
+	assert.EqualError(t, err, "Put only supports ReturnOld, but ALL_NEW return values were requested: ReturnNew is only supported by Update")
+
+	err = c.Delete(ctx, fakeThing{ID: "1"}, ReturnNew(&got))
+	assert.EqualError(t, err, "Delete only supports ReturnOld, but ALL_NEW return values were requested: ReturnNew is only supported by Update")
+
+	err = c.Put(ctx, fakeThing{ID: "1"}, func(wo *WriteOpts) { wo.ReturnValues = types.ReturnValueUpdatedOld })
+	assert.Error(t, err)
+}
@@ -0,0 +1,159 @@
+package ddb
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// VersionAttribute is the attribute used to store the version of
+// items which implement Versioned.
+const VersionAttribute = "ddb:version"
+
+// Versioned items are written with optimistic locking.
+//
+// When a Versioned item is written with Put, Delete or in a transaction,
+// the write only succeeds if the version stored in DynamoDB matches
+// DDBVersion(). Puts increment the stored version, and call SetDDBVersion()
+// with the new version once the write succeeds. If the item has been modified
+// concurrently, ErrVersionConflict is returned. If the write has other conditions,
+// such as IfExists(), ErrConditionFailed is returned instead.
+//
+// The version is stored in the 'ddb:version' attribute. To read it back,
+// add a field to your item with a `dynamodbav:"ddb:version"` struct tag.
+//
+// As an alternative to implementing Versioned, you can add a `ddb:"version"`
+// struct tag to an integer field on your item. In this case the version
+// is stored in the field's attribute.
+//
+// An item with a version of 0 is treated as new, and can only be
+// written if it doesn't already exist.
+//
+// PutBatch and DeleteBatch can't check versions, so they return an
+// error if any of the items are versioned.
+type Versioned interface {
+	DDBVersion() int64
+	SetDDBVersion(version int64)
+}
+
+// itemVersion is the optimistic locking version of an item.
+type itemVersion struct {
+	// attr is the attribute name the version is stored in.
+	attr string
+	// current is the version of the item held in memory.
+	current int64
+	// set updates the version on the item. It is nil if the item
+	// was not passed by reference, so it can't be updated.
+	set func(int64)
+}
+
+// versionOf returns the version of an item, or nil if
+// the item doesn't use optimistic locking.
+func versionOf(k Keyer) (*itemVersion, error) {
+	item := Unwrap(k)
+	if v, ok := item.(Versioned); ok {
+		return &itemVersion{
+			attr:    VersionAttribute,
+			current: v.DDBVersion(),
+			set:     v.SetDDBVersion,
+		}, nil
+	}
+
+	v := reflect.ValueOf(item)
+	settable := false
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+		settable = true
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if tag, ok := f.Tag.Lookup("ddb"); !ok || tag != "version" {
+			continue
+		}
+
+		attr := f.Name
+		if av, ok := f.Tag.Lookup("dynamodbav"); ok {
+			name := strings.Split(av, ",")[0]
+			if name == "-" {
+				return nil, fmt.Errorf("version field %s must not be excluded from marshalling", f.Name)
+			}
+			if name != "" {
+				attr = name
+			}
+		}
+
+		field := v.Field(i)
+		version := &itemVersion{attr: attr}
+
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			version.current = field.Int()
+			if settable {
+				version.set = func(n int64) { field.SetInt(n) }
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			version.current = int64(field.Uint())
+			if settable {
+				version.set = func(n int64) { field.SetUint(uint64(n)) }
+			}
+		default:
+			return nil, fmt.Errorf("version field %s must be an integer, got %s", f.Name, field.Kind())
+		}
+		return version, nil
+	}
+	return nil, nil
+}
+
+// checkNotVersioned returns an error if any of the items use optimistic locking.
+// BatchWriteItem doesn't support conditions, so batch writes can't check
+// or increment the version of an item.
+func checkNotVersioned(op string, items []Keyer) error {
+	for _, item := range items {
+		version, err := versionOf(item)
+		if err != nil {
+			return err
+		}
+		if version != nil {
+			return fmt.Errorf("%s doesn't support optimistic locking, but %T is versioned: use Put, Delete or a transaction to write versioned items", op, Unwrap(item))
+		}
+	}
+	return nil
+}
+
+// condition returns a condition expression which is met only if the
+// version stored in DynamoDB matches the current version.
+func (v *itemVersion) condition() ConditionExpression {
+	names := map[string]string{"#ddbversion": v.attr}
+	if v.current == 0 {
+		return ConditionExpression{
+			Expression: "attribute_not_exists(#ddbversion)",
+			Names:      names,
+		}
+	}
+	return ConditionExpression{
+		Expression: "#ddbversion = :ddbversion",
+		Names:      names,
+		Values: map[string]types.AttributeValue{
+			":ddbversion": &types.AttributeValueMemberN{Value: strconv.FormatInt(v.current, 10)},
+		},
+	}
+}
+
+// next returns the version to be written for a put.
+func (v *itemVersion) next() types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(v.current+1, 10)}
+}
+
+// update sets the version on the item after a successful put.
+func (v *itemVersion) update() {
+	if v.set != nil {
+		v.set(v.current + 1)
+	}
+}
@@ -0,0 +1,104 @@
+package ddb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type versionedItem struct {
+	Version int64 `dynamodbav:"ddb:version"`
+}
+
+func (v *versionedItem) DDBKeys() (Keys, error) { return Keys{PK: "PK", SK: "SK"}, nil }
+
+func (v *versionedItem) DDBVersion() int64 { return v.Version }
+
+func (v *versionedItem) SetDDBVersion(version int64) { v.Version = version }
+
+type taggedVersionItem struct {
+	Rev uint32 `ddb:"version" dynamodbav:"rev"`
+}
+
+func (t taggedVersionItem) DDBKeys() (Keys, error) { return Keys{PK: "PK", SK: "SK"}, nil }
+
+type invalidVersionItem struct {
+	Version string `ddb:"version"`
+}
+
+func (i invalidVersionItem) DDBKeys() (Keys, error) { return Keys{PK: "PK", SK: "SK"}, nil }
+
+func Test_versionOf(t *testing.T) {
+	tests := []struct {
+		name        string
+		give        Keyer
+		wantAttr    string
+		wantCurrent int64
+		wantNil     bool
+		wantErr     bool
+	}{
+		{
+			name:        "versioned interface",
+			give:        &versionedItem{Version: 2},
+			wantAttr:    VersionAttribute,
+			wantCurrent: 2,
+		},
+		{
+			name:        "struct tag",
+			give:        &taggedVersionItem{Rev: 3},
+			wantAttr:    "rev",
+			wantCurrent: 3,
+		},
+		{
+			name:        "struct tag not passed by reference",
+			give:        taggedVersionItem{Rev: 3},
+			wantAttr:    "rev",
+			wantCurrent: 3,
+		},
+		{
+			name:    "not versioned",
+			give:    testitem{},
+			wantNil: true,
+		},
+		{
+			name:    "invalid field type",
+			give:    invalidVersionItem{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := versionOf(tt.give)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("versionOf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantNil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.wantAttr, got.attr)
+			assert.Equal(t, tt.wantCurrent, got.current)
+		})
+	}
+}
+
+func TestItemVersionUpdate(t *testing.T) {
+	tagged := &taggedVersionItem{Rev: 1}
+	v, err := versionOf(tagged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.update()
+	assert.Equal(t, uint32(2), tagged.Rev)
+
+	item := &versionedItem{}
+	v, err = versionOf(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.update()
+	assert.Equal(t, int64(1), item.Version)
+}